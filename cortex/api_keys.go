@@ -0,0 +1,118 @@
+package cortex
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// How long a key is skipped after a 429 without a reset or Retry-After header
+const DefaultThrottledBackoff = 5 * time.Second
+
+// Requests of a connection are rotated across its keys, so the pools are shared per base URL
+// and keys to keep the rotation and rate limit state across table scans.
+var apiKeyPools = struct {
+	sync.Mutex
+	byConnection map[string]*apiKeyPool
+}{byConnection: make(map[string]*apiKeyPool)}
+
+type apiKeyPool struct {
+	mu   sync.Mutex
+	keys []*apiKeyState
+	next int
+}
+
+// The rate limit state of a key from its last response
+type apiKeyState struct {
+	key       string
+	remaining *int
+	resetTime time.Time
+	throttled bool
+}
+
+func getAPIKeyPool(baseURL string, keys []string) *apiKeyPool {
+	apiKeyPools.Lock()
+	defer apiKeyPools.Unlock()
+	name := baseURL + "\x00" + strings.Join(keys, "\x00")
+	pool, ok := apiKeyPools.byConnection[name]
+	if !ok {
+		pool = &apiKeyPool{}
+		for _, key := range keys {
+			pool.keys = append(pool.keys, &apiKeyState{key: key})
+		}
+		apiKeyPools.byConnection[name] = pool
+	}
+	return pool
+}
+
+func (p *apiKeyPool) size() int {
+	return len(p.keys)
+}
+
+// Take the next key round-robin, skipping keys with no requests left until their window resets.
+// When every key is exhausted the one resetting first is used, the API decides whether to throttle it.
+func (p *apiKeyPool) pick(now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) == 0 {
+		return ""
+	}
+	var earliest *apiKeyState
+	for i := range p.keys {
+		state := p.keys[(p.next+i)%len(p.keys)]
+		if !state.exhausted(now) {
+			p.next = (p.next + i + 1) % len(p.keys)
+			return state.key
+		}
+		if earliest == nil || state.resetTime.Before(earliest.resetTime) {
+			earliest = state
+		}
+	}
+	p.next = (p.next + 1) % len(p.keys)
+	return earliest.key
+}
+
+// Record the rate limit headers of a response made with key
+func (p *apiKeyPool) record(key string, resp *req.Response, now time.Time) {
+	if resp.Response == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, state := range p.keys {
+		if state.key != key {
+			continue
+		}
+		if remaining, err := strconv.Atoi(resp.GetHeader(RateLimitRemainingHeader)); err == nil {
+			state.remaining = &remaining
+		}
+		if reset, ok := parseRateLimitReset(resp.GetHeader(RateLimitResetHeader), now); ok {
+			state.resetTime = reset
+		}
+		state.throttled = resp.StatusCode == http.StatusTooManyRequests
+		if state.throttled {
+			if reset, ok := parseRateLimitReset(resp.GetHeader(RetryAfterHeader), now); ok {
+				state.resetTime = reset
+			} else if !state.resetTime.After(now) {
+				state.resetTime = now.Add(DefaultThrottledBackoff)
+			}
+		}
+		return
+	}
+}
+
+func (s *apiKeyState) exhausted(now time.Time) bool {
+	if !s.resetTime.After(now) {
+		return false
+	}
+	return s.throttled || (s.remaining != nil && *s.remaining <= 0)
+}
+
+// The key a request was sent with, from its Authorization header
+func requestAPIKey(r *req.Request) string {
+	return strings.TrimPrefix(r.Headers.Get("Authorization"), "Bearer ")
+}
@@ -0,0 +1,99 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/imroc/req/v3"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestGetConfigApiKeys(t *testing.T) {
+	g := NewWithT(t)
+	apiKey := "api_key"
+	g.Expect((&SteampipeConfig{}).GetApiKeys()).To(BeEmpty())
+	g.Expect((&SteampipeConfig{ApiKey: &apiKey}).GetApiKeys()).To(Equal([]string{"api_key"}))
+	g.Expect((&SteampipeConfig{ApiKey: &apiKey, ApiKeys: []string{"key1", "key2"}}).GetApiKeys()).To(Equal([]string{"key1", "key2"}))
+
+	// The environment overrides both
+	t.Setenv("CORTEX_API_KEY", "env_api_key")
+	config := GetConfig(&plugin.Connection{Config: SteampipeConfig{ApiKeys: []string{"key1", "key2"}}})
+	g.Expect(config.GetApiKeys()).To(Equal([]string{"env_api_key"}))
+}
+
+func TestAPIKeyPoolPick(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	pool := getAPIKeyPool("https://pick.example.com", []string{"key1", "key2", "key3"})
+
+	// Round-robin
+	g.Expect(pool.pick(now)).To(Equal("key1"))
+	g.Expect(pool.pick(now)).To(Equal("key2"))
+	g.Expect(pool.pick(now)).To(Equal("key3"))
+	g.Expect(pool.pick(now)).To(Equal("key1"))
+
+	// Keys with no requests left are skipped until their window resets
+	exhausted := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	exhausted.Header.Set(RateLimitRemainingHeader, "0")
+	exhausted.Header.Set(RateLimitResetHeader, "60")
+	pool.record("key2", &req.Response{Response: exhausted}, now)
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	pool.record("key3", &req.Response{Response: throttled}, now)
+	g.Expect(pool.pick(now)).To(Equal("key1"))
+	g.Expect(pool.pick(now)).To(Equal("key1"))
+	g.Expect(pool.pick(now.Add(DefaultThrottledBackoff))).To(Equal("key3"))
+	g.Expect(pool.pick(now.Add(time.Minute))).To(Equal("key1"))
+	g.Expect(pool.pick(now.Add(time.Minute))).To(Equal("key2"))
+
+	// With every key exhausted the first to reset is used
+	pool.record("key1", &req.Response{Response: throttled}, now)
+	pool.record("key3", &req.Response{Response: exhausted}, now)
+	g.Expect(pool.pick(now)).To(Equal("key1"))
+}
+
+func TestCortexHTTPClientRotatesApiKeys(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	entity := `{"tag": "service1", "type": "service"}`
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyHeaderKV("Authorization", "Bearer key1"),
+			gh.RespondWith(http.StatusOK, entity, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyHeaderKV("Authorization", "Bearer key2"),
+			gh.RespondWith(http.StatusTooManyRequests, "{}", http.Header{RetryAfterHeader: []string{"60"}}),
+		),
+		// The throttled call is retried with the other key
+		ghttp.CombineHandlers(
+			gh.VerifyHeaderKV("Authorization", "Bearer key1"),
+			gh.RespondWith(http.StatusOK, entity, nil),
+		),
+		// key2 is skipped until it resets
+		ghttp.CombineHandlers(
+			gh.VerifyHeaderKV("Authorization", "Bearer key1"),
+			gh.RespondWith(http.StatusOK, entity, nil),
+		),
+	)
+	for _, key := range []string{"key1", "key2"} {
+		getCredentialCheck(server.URL(), key).done = true
+	}
+
+	config := NewSteampipeConfig("", server.URL())
+	config.ApiKeys = []string{"key1", "key2"}
+	for _, tag := range []string{"service1", "service2", "service3"} {
+		_, err := getEntity(ctx, CortexHTTPClient(ctx, config), tag, EntityIncludes{})
+		g.Expect(err).To(BeNil())
+	}
+	g.Expect(server.ReceivedRequests()).To(HaveLen(4))
+}
@@ -0,0 +1,81 @@
+package cortex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Consecutive failed API calls before the breaker opens
+const CircuitBreakerThreshold = 5
+
+// How long an open breaker fails calls fast before letting one through again
+const CircuitBreakerCooldown = 30 * time.Second
+
+// Clients are created per hydrate call, so breakers are shared per base URL to
+// remember failures across scans.
+var circuitBreakers = struct {
+	sync.Mutex
+	byBaseURL map[string]*circuitBreaker
+}{byBaseURL: make(map[string]*circuitBreaker)}
+
+func getCircuitBreaker(baseURL string) *circuitBreaker {
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+	breaker, ok := circuitBreakers.byBaseURL[baseURL]
+	if !ok {
+		breaker = &circuitBreaker{threshold: CircuitBreakerThreshold, cooldown: CircuitBreakerCooldown}
+		circuitBreakers.byBaseURL[baseURL] = breaker
+	}
+	return breaker
+}
+
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+// Error if the breaker is open, once the cool-down has passed calls are let through again
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold && time.Now().Before(b.openUntil) {
+		return fmt.Errorf("cortex API failed %d times in a row, not calling it again until %s", b.failures, b.openUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Count a failure and open the breaker once the threshold is reached, any success closes it
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// Only count errors that suggest the API is down, a 404 or 400 says nothing about its health
+func isAPIFailure(resp *req.Response) bool {
+	if isCancelled(resp) {
+		return false
+	}
+	return resp.Err != nil || resp.Response == nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// Steampipe cancels in-flight calls on LIMIT and Ctrl-C, these say nothing about the API either way
+func isCancelled(resp *req.Response) bool {
+	return errors.Is(resp.Err, context.Canceled) || errors.Is(resp.Err, context.DeadlineExceeded)
+}
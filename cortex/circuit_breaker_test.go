@@ -0,0 +1,84 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	g := NewWithT(t)
+	breaker := &circuitBreaker{threshold: 2, cooldown: time.Minute}
+
+	breaker.record(true)
+	g.Expect(breaker.allow()).To(BeNil())
+	breaker.record(true)
+	g.Expect(breaker.allow()).ToNot(BeNil())
+	g.Expect(breaker.allow().Error()).To(HavePrefix("cortex API failed 2 times in a row, not calling it again until "))
+
+	// A success closes the breaker again
+	breaker.record(false)
+	g.Expect(breaker.allow()).To(BeNil())
+}
+
+func TestCircuitBreakerCooldown(t *testing.T) {
+	g := NewWithT(t)
+	breaker := &circuitBreaker{threshold: 1, cooldown: -time.Second}
+
+	breaker.record(true)
+	g.Expect(breaker.allow()).To(BeNil())
+}
+
+func TestCircuitBreakerOpensOnServerErrors(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	var handlers []http.HandlerFunc
+	for i := 0; i < CircuitBreakerThreshold; i++ {
+		handlers = append(handlers, ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusServiceUnavailable, "{}", nil),
+		))
+	}
+	ctx, server, client := setupTestServerAndClient(t, handlers...)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+	for i := 0; i < CircuitBreakerThreshold; i++ {
+		err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+		g.Expect(err.Error()).To(Equal("error from cortex API 503 Service Unavailable: {}"))
+	}
+
+	// Fails fast without calling the API, also for clients of later scans
+	client = CortexHTTPClient(ctx, NewSteampipeConfig("fake_api_key", server.URL()))
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(HavePrefix("cortex API failed 5 times in a row"))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(CircuitBreakerThreshold))
+}
+
+func TestCircuitBreakerIgnoresCancelledCalls(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx, server, client := setupTestServerAndClient(t)
+	defer server.Close()
+	breaker := getCircuitBreaker(server.URL())
+	breaker.record(true)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < CircuitBreakerThreshold; i++ {
+		resp := client.Get("/api/v1/catalog").Do(cancelled)
+		g.Expect(resp.Err).To(MatchError(context.Canceled))
+		g.Expect(isAPIFailure(resp)).To(BeFalse())
+	}
+	g.Expect(breaker.allow()).To(BeNil())
+
+	// Cancelled calls don't close the breaker either
+	g.Expect(breaker.failures).To(Equal(1))
+	g.Expect(server.ReceivedRequests()).To(BeEmpty())
+}
@@ -0,0 +1,71 @@
+package cortex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// Returned when Cortex rejects the API key, rather than a generic 401 from whichever call hit it first
+const InvalidCredentialsError = "401 from Cortex: check api_key in ~/.steampipe/config/cortex.spc or the CORTEX_API_KEY environment variable"
+
+// Clients are created per hydrate call, so checks are shared per base URL and key
+// to only validate the key on the first call of a connection.
+var credentialChecks = struct {
+	sync.Mutex
+	byConnection map[string]*credentialCheck
+}{byConnection: make(map[string]*credentialCheck)}
+
+type credentialCheck struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+func getCredentialCheck(baseURL string, apiKey string) *credentialCheck {
+	credentialChecks.Lock()
+	defer credentialChecks.Unlock()
+	key := baseURL + "\x00" + apiKey
+	check, ok := credentialChecks.byConnection[key]
+	if !ok {
+		check = &credentialCheck{}
+		credentialChecks.byConnection[key] = check
+	}
+	return check
+}
+
+// Validate the key with a single-entity catalog page, concurrent scans wait for the first check.
+// A 401 fails every call of the connection, any other response but a 5xx means the key is valid.
+// Transport errors and 5xx are not remembered, they are left to the real call to report.
+func (c *credentialCheck) run(ctx context.Context, baseURL string, apiKey string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return c.err
+	}
+
+	resp := cortexapi.NewClient(baseURL, apiKey).
+		SetCommonRetryCount(0).
+		Get("/api/v1/catalog").
+		SetQueryParam("pageSize", "1").
+		SetQueryParam("page", "0").
+		Do(ctx)
+
+	if resp.Err != nil || resp.Response == nil {
+		plugin.Logger(ctx).Warn("checkCredentials", "Error", resp.Err)
+		return nil
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		plugin.Logger(ctx).Error("checkCredentials", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader))
+		c.done, c.err = true, errors.New(InvalidCredentialsError)
+	case resp.StatusCode < http.StatusInternalServerError:
+		// Any other answer means the key was accepted, e.g. a 403 from a key without catalog access
+		c.done = true
+	}
+	return c.err
+}
@@ -0,0 +1,101 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestCredentialCheckInvalidKey(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(ghttp.CombineHandlers(
+		gh.VerifyRequest("GET", "/api/v1/catalog", "pageSize=1&page=0"),
+		gh.VerifyHeaderKV("Authorization", "Bearer bad_api_key"),
+		gh.RespondWith(http.StatusUnauthorized, "{}", nil),
+	))
+
+	client := CortexHTTPClient(ctx, NewSteampipeConfig("bad_api_key", server.URL()))
+	writer := NewSliceWriter[CortexEntityElement](100)
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring(InvalidCredentialsError))
+
+	// Later scans fail fast without calling the API again
+	client = CortexHTTPClient(ctx, NewSteampipeConfig("bad_api_key", server.URL()))
+	err = listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring(InvalidCredentialsError))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(1))
+}
+
+func TestCredentialCheckValidKey(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog", "pageSize=1&page=0"),
+			gh.RespondWith(http.StatusOK, "{}", nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.RespondWith(http.StatusOK, "{}", nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.RespondWith(http.StatusOK, "{}", nil),
+		),
+	)
+
+	// Only the first call of the connection checks the key
+	client := CortexHTTPClient(ctx, NewSteampipeConfig("good_api_key", server.URL()))
+	_, err := getTeamRelationships(ctx, client)
+	g.Expect(err).To(BeNil())
+	_, err = getTeamRelationships(ctx, client)
+	g.Expect(err).To(BeNil())
+	g.Expect(server.ReceivedRequests()).To(HaveLen(3))
+}
+
+func TestCredentialCheckForbiddenKey(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog", "pageSize=1&page=0"),
+			gh.RespondWith(http.StatusForbidden, "{}", nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.RespondWith(http.StatusOK, "{}", nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.RespondWith(http.StatusOK, "{}", nil),
+		),
+	)
+
+	// A key without catalog access is still valid, the check is not repeated
+	client := CortexHTTPClient(ctx, NewSteampipeConfig("forbidden_api_key", server.URL()))
+	_, err := getTeamRelationships(ctx, client)
+	g.Expect(err).To(BeNil())
+	_, err = getTeamRelationships(ctx, client)
+	g.Expect(err).To(BeNil())
+	g.Expect(server.ReceivedRequests()).To(HaveLen(3))
+}
@@ -0,0 +1,153 @@
+package cortex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Column types for the JSON schema types of a custom entity type's properties, others are JSON
+var definitionColumnTypes = map[string]proto.ColumnType{
+	"string":  proto.ColumnType_STRING,
+	"integer": proto.ColumnType_INT,
+	"number":  proto.ColumnType_DOUBLE,
+	"boolean": proto.ColumnType_BOOL,
+}
+
+// A property of a custom entity type's definition schema
+type definitionProperty struct {
+	Key         string
+	Type        string
+	Description string
+}
+
+// Table name for a custom entity type, e.g. rds-instance becomes cortex_rds_instance
+func customEntityTableName(entityType string) string {
+	return "cortex_" + invalidColumnChars.ReplaceAllString(strings.ToLower(entityType), "_")
+}
+
+// Properties of the definition schema sorted by key, so columns keep their order between reloads
+func definitionProperties(schema map[string]interface{}) []definitionProperty {
+	properties, _ := schema["properties"].(map[string]interface{})
+	var result []definitionProperty
+	for key, value := range properties {
+		property, _ := value.(map[string]interface{})
+		typeName, _ := property["type"].(string)
+		description, _ := property["description"].(string)
+		result = append(result, definitionProperty{Key: key, Type: typeName, Description: description})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// A table of the entities of a custom type, with a column for each property of the type's definition schema
+func tableCortexCustomEntity(ctx context.Context, entityType CortexEntityType) *plugin.Table {
+	name := customEntityTableName(entityType.Type)
+	table := &plugin.Table{
+		Name:        name,
+		Description: fmt.Sprintf("Cortex entities of the custom %s type.", entityType.Type),
+		List: &plugin.ListConfig{
+			Hydrate: listCustomEntitiesHydrator(entityType.Type),
+		},
+		Columns: []*plugin.Column{
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity."},
+			{Name: "tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description."},
+			{Name: "groups", Type: proto.ColumnType_JSON, Description: "Groups, kind of like tags."},
+			{Name: "owner_teams", Type: proto.ColumnType_JSON, Description: "List of owning team tags", Transform: FromStructSlice[CortexEntityOwnersTeam]("Owners.Teams", "Tag")},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Last updated time.", Transform: transform.FromField("LastUpdated").Transform(ToUTCTimestamp)},
+			{Name: "definition", Type: proto.ColumnType_JSON, Description: "Raw x-cortex-definition of the entity."},
+		},
+	}
+
+	existing := make(map[string]bool, len(table.Columns))
+	for _, column := range table.Columns {
+		existing[column.Name] = true
+	}
+	for _, property := range definitionProperties(entityType.Schema) {
+		columnName := invalidColumnChars.ReplaceAllString(strings.ToLower(property.Key), "_")
+		if existing[columnName] {
+			plugin.Logger(ctx).Warn("tableCortexCustomEntity", "table", name, "skipping property", property.Key)
+			continue
+		}
+		existing[columnName] = true
+		columnType, ok := definitionColumnTypes[property.Type]
+		if !ok {
+			columnType = proto.ColumnType_JSON
+		}
+		description := property.Description
+		if description == "" {
+			description = fmt.Sprintf("The %s property of the definition.", property.Key)
+		}
+		table.Columns = append(table.Columns, &plugin.Column{
+			Name:        columnName,
+			Type:        columnType,
+			Description: description,
+			Transform:   transform.FromField("Definition").TransformP(MetadataValue, property.Key),
+		})
+	}
+	return table
+}
+
+func listCustomEntitiesHydrator(entityType string) plugin.HydrateFunc {
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+		config := GetTableConfig(d)
+		client := CortexHTTPClient(ctx, config)
+		hydratorWriter := QueryDataWriter{d}
+		return nil, listEntities(ctx, client, &hydratorWriter, "false", entityType, "", EntityIncludes{Owners: true})
+	}
+}
+
+// Tables for the custom entity types of the workspace, types whose table name is taken are skipped.
+// Without credentials nothing can be fetched, so the plugin only has its static tables.
+func customEntityTables(ctx context.Context, config *SteampipeConfig, tables map[string]*plugin.Table) map[string]*plugin.Table {
+	custom := make(map[string]*plugin.Table)
+	noCredentials := config.GetSource() == SourceAPI && len(config.GetApiKeys()) == 0
+	if noCredentials || config.BaseURL == nil {
+		return custom
+	}
+	logger := plugin.Logger(ctx)
+
+	definitions, err := getEntityTypeDefinitions(ctx, CortexHTTPClient(ctx, config))
+	if err != nil {
+		logger.Warn("customEntityTables", "Error", err)
+		return custom
+	}
+	for _, definition := range definitions {
+		name := customEntityTableName(definition.Type)
+		if _, ok := tables[name]; ok {
+			logger.Warn("customEntityTables", "type", definition.Type, "table name taken", name)
+			continue
+		}
+		custom[name] = tableCortexCustomEntity(ctx, definition)
+	}
+	logger.Info("customEntityTables", "tables", len(custom))
+	return custom
+}
+
+// Pending schema refreshes by connection name, defining the tables again replaces the connection's timer
+var schemaRefreshes = struct {
+	sync.Mutex
+	byConnection map[string]*time.Timer
+}{byConnection: make(map[string]*time.Timer)}
+
+// Call refresh once the interval has passed, a zero interval only cancels the pending refresh.
+// refresh defines the tables again, which schedules the next refresh.
+func scheduleSchemaRefresh(connection string, interval time.Duration, refresh func()) {
+	schemaRefreshes.Lock()
+	defer schemaRefreshes.Unlock()
+	if timer, ok := schemaRefreshes.byConnection[connection]; ok {
+		timer.Stop()
+		delete(schemaRefreshes.byConnection, connection)
+	}
+	if interval > 0 {
+		schemaRefreshes.byConnection[connection] = time.AfterFunc(interval, refresh)
+	}
+}
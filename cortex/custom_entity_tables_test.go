@@ -0,0 +1,137 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+func TestCustomEntityTableName(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(customEntityTableName("rds-instance")).To(Equal("cortex_rds_instance"))
+	g.Expect(customEntityTableName("Queue")).To(Equal("cortex_queue"))
+}
+
+func TestTableCortexCustomEntity(t *testing.T) {
+	g := NewWithT(t)
+	ctx, server, _ := setupTestServerAndClient(t)
+	defer server.Close()
+
+	table := tableCortexCustomEntity(ctx, CortexEntityType{Type: "rds-instance", Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"engine":       map[string]interface{}{"type": "string", "description": "Database engine."},
+			"storage-gb":   map[string]interface{}{"type": "integer"},
+			"multi_az":     map[string]interface{}{"type": "boolean"},
+			"parameters":   map[string]interface{}{"type": "object"},
+			"tag":          map[string]interface{}{"type": "string"},
+			"cpu_fraction": map[string]interface{}{"type": "number"},
+		},
+	}})
+
+	// Check basic table properties.
+	g.Expect(table.Name).To(Equal("cortex_rds_instance"))
+	g.Expect(table.Description).To(Equal("Cortex entities of the custom rds-instance type."))
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns, properties clashing with the entity columns are skipped.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"name", proto.ColumnType_STRING},
+		{"tag", proto.ColumnType_STRING},
+		{"description", proto.ColumnType_STRING},
+		{"groups", proto.ColumnType_JSON},
+		{"owner_teams", proto.ColumnType_JSON},
+		{"last_updated", proto.ColumnType_TIMESTAMP},
+		{"definition", proto.ColumnType_JSON},
+		{"cpu_fraction", proto.ColumnType_DOUBLE},
+		{"engine", proto.ColumnType_STRING},
+		{"multi_az", proto.ColumnType_BOOL},
+		{"parameters", proto.ColumnType_JSON},
+		{"storage_gb", proto.ColumnType_INT},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+	g.Expect(table.Columns[8].Description).To(Equal("Database engine."))
+
+	// Property columns come from the entity's definition
+	entity := CortexEntityElement{Tag: "db1", Definition: map[string]interface{}{"storage-gb": 100}}
+	value, err := table.Columns[11].Transform.Execute(ctx, &transform.TransformData{HydrateItem: entity})
+	g.Expect(err).To(BeNil())
+	g.Expect(value).To(Equal(100))
+}
+
+func TestPluginTableDefinitionsCustomEntityTypes(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	definitions := prepareEntityTypeResponse(t, []CortexEntityType{
+		{Type: "rds-instance", Schema: map[string]interface{}{"properties": map[string]interface{}{"engine": map[string]interface{}{"type": "string"}}}},
+		// Would replace a static table
+		{Type: "entity-type"},
+	}, 0, 1, 2)
+
+	ctx, server, _ := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/definitions"),
+			gh.RespondWith(http.StatusOK, definitions, nil),
+		),
+	)
+	defer server.Close()
+
+	connection := &plugin.Connection{Config: *NewSteampipeConfig("fake_api_key", server.URL())}
+	tables, err := pluginTableDefinitions(ctx, &plugin.TableMapData{Connection: connection})
+	g.Expect(err).To(BeNil())
+	g.Expect(tables).To(HaveKey("cortex_rds_instance"))
+	g.Expect(getColumn(tables["cortex_rds_instance"], "engine")).ToNot(BeNil())
+	g.Expect(tables["cortex_entity_type"].Description).To(Equal("Cortex entity types, built-in and custom, with the number of entities of each type."))
+}
+
+func TestPluginTableDefinitionsCustomEntityTypesError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, _ := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/definitions"),
+			gh.RespondWith(http.StatusForbidden, "{}", nil),
+		),
+	)
+	defer server.Close()
+
+	// The static tables are still defined
+	connection := &plugin.Connection{Config: *NewSteampipeConfig("fake_api_key", server.URL())}
+	tables, err := pluginTableDefinitions(ctx, &plugin.TableMapData{Connection: connection})
+	g.Expect(err).To(BeNil())
+	g.Expect(tables).To(HaveKey("cortex_entity"))
+	g.Expect(tables).ToNot(HaveKey("cortex_rds_instance"))
+}
+
+func TestScheduleSchemaRefresh(t *testing.T) {
+	g := NewWithT(t)
+
+	refreshed := make(chan string, 2)
+	scheduleSchemaRefresh("test", time.Millisecond, func() { refreshed <- "first" })
+	g.Eventually(refreshed).Should(Receive(Equal("first")))
+
+	// Scheduling again replaces the pending refresh, a zero interval cancels it
+	scheduleSchemaRefresh("test", time.Hour, func() { refreshed <- "second" })
+	scheduleSchemaRefresh("test", 0, nil)
+	g.Consistently(refreshed, 20*time.Millisecond).ShouldNot(Receive())
+	g.Expect(schemaRefreshes.byConnection).ToNot(HaveKey("test"))
+}
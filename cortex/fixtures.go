@@ -0,0 +1,73 @@
+package cortex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+)
+
+// Where table data is read from, the Cortex API or a directory of fixtures
+const (
+	SourceAPI  = "api"
+	SourceFile = "file"
+)
+
+// Fixture files are looked up by the API path with one of these extensions
+var fixtureExtensions = []string{".json", ".yaml", ".yml"}
+
+// A client answering every call from the fixtures in dir rather than the Cortex API
+func fixtureHTTPClient(baseURL string, dir string) *req.Client {
+	client := cortexapi.NewClient(baseURL, "").SetCommonRetryCount(0)
+	client.GetTransport().WrapRoundTripFunc(fixtureRoundTrip(dir))
+	return client
+}
+
+// Respond with the fixture for the request path, e.g. api/v1/catalog.json for GET /api/v1/catalog.
+// Query params are ignored, so a fixture is returned whole for any filter or page.
+func fixtureRoundTrip(dir string) req.HttpRoundTripWrapperFunc {
+	return func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			status := http.StatusOK
+			body, err := readFixture(dir, r.URL.Path)
+			if errors.Is(err, fs.ErrNotExist) {
+				status = http.StatusNotFound
+				body = []byte(fmt.Sprintf(`{"message": "no fixture for %s in %s"}`, r.URL.Path, dir))
+			} else if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+				StatusCode:    status,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": []string{"application/json"}},
+				Body:          io.NopCloser(bytes.NewReader(body)),
+				ContentLength: int64(len(body)),
+				Request:       r,
+			}, nil
+		}
+	}
+}
+
+func readFixture(dir string, urlPath string) ([]byte, error) {
+	// Cleaning from the root keeps tags with ../ inside the directory
+	name := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path.Clean("/"+urlPath), "/")))
+	for _, extension := range fixtureExtensions {
+		body, err := os.ReadFile(name + extension)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return body, err
+		}
+	}
+	return nil, fs.ErrNotExist
+}
@@ -0,0 +1,70 @@
+package cortex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	. "github.com/onsi/gomega"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func writeFixture(t *testing.T, dir string, name string, body string) {
+	t.Helper()
+	name = filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatalf("Failed to create fixture dir: %v", err)
+	}
+	if err := os.WriteFile(name, []byte(body), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+}
+
+func TestFixtureHTTPClient(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	writeFixture(t, dir, "api/v1/catalog.json", `{"entities": [{"tag": "service1", "type": "service"}], "page": 0, "totalPages": 1, "total": 1}`)
+	writeFixture(t, dir, "api/v1/catalog/service2.yaml", "tag: service2\ntype: service\n")
+
+	source, fixturesDir := SourceFile, dir
+	config := NewSteampipeConfig("", DefaultBaseURL)
+	config.Source, config.FixturesDir = &source, &fixturesDir
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+	client := CortexHTTPClient(ctx, config)
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+	err = getEntityByTag(ctx, client, writer, "service2", "false", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+	// Missing fixtures are not found
+	err = getEntityByTag(ctx, client, writer, "missing", "false", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].Tag).To(Equal("service1"))
+	g.Expect(writer.Items[1].Tag).To(Equal("service2"))
+
+	// Paths can't leave the fixtures dir
+	writeFixture(t, filepath.Dir(dir), "secret.json", `{}`)
+	_, err = readFixture(dir, "/../secret")
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestPluginTableDefinitionsSource(t *testing.T) {
+	g := NewWithT(t)
+
+	source := "file"
+	connection := &plugin.Connection{Config: SteampipeConfig{Source: &source}}
+	_, err := pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("source \"file\" needs fixtures_dir to be set"))
+
+	source = "nope"
+	_, err = pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("source should be \"api\" or \"file\", got \"nope\""))
+}
@@ -0,0 +1,79 @@
+package cortex
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Column types a metadata_columns entry can ask for with a "key:type" suffix
+var metadataColumnTypes = map[string]proto.ColumnType{
+	"string": proto.ColumnType_STRING,
+	"int":    proto.ColumnType_INT,
+	"double": proto.ColumnType_DOUBLE,
+	"bool":   proto.ColumnType_BOOL,
+	"json":   proto.ColumnType_JSON,
+}
+
+var invalidColumnChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// A metadata key surfaced as a top-level column
+type MetadataColumn struct {
+	Key  string
+	Name string
+	Type proto.ColumnType
+}
+
+// Parse metadata_columns entries such as "cost_center" or "tier:int", the type defaults to string.
+// Column names are the lower cased key with other characters replaced by underscores.
+func ParseMetadataColumns(entries []string) ([]MetadataColumn, error) {
+	var columns []MetadataColumn
+	for _, entry := range entries {
+		key, typeName, found := strings.Cut(entry, ":")
+		if !found {
+			typeName = "string"
+		}
+		columnType, ok := metadataColumnTypes[typeName]
+		if key == "" || !ok {
+			return nil, fmt.Errorf("invalid metadata_columns entry %q, expected \"key\" or \"key:type\" with type one of string, int, double, bool, json", entry)
+		}
+		name := invalidColumnChars.ReplaceAllString(strings.ToLower(key), "_")
+		columns = append(columns, MetadataColumn{Key: key, Name: name, Type: columnType})
+	}
+	return columns, nil
+}
+
+// Add the metadata columns to a table, transform gets the metadata as a map from the row.
+func addMetadataColumns(table *plugin.Table, columns []MetadataColumn, metadata func() *transform.ColumnTransforms) error {
+	existing := make(map[string]bool, len(table.Columns))
+	for _, column := range table.Columns {
+		existing[column.Name] = true
+	}
+	for _, column := range columns {
+		if existing[column.Name] {
+			return fmt.Errorf("metadata_columns entry %q clashes with column %s of %s", column.Key, column.Name, table.Name)
+		}
+		existing[column.Name] = true
+		table.Columns = append(table.Columns, &plugin.Column{
+			Name:        column.Name,
+			Type:        column.Type,
+			Description: fmt.Sprintf("The %s custom metadata value.", column.Key),
+			Transform:   metadata().TransformP(MetadataValue, column.Key),
+		})
+	}
+	return nil
+}
+
+// Get the value of the metadata key given as the param
+func MetadataValue(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	metadata, ok := d.Value.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return metadata[d.Param.(string)], nil
+}
@@ -0,0 +1,76 @@
+package cortex
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+func TestParseMetadataColumns(t *testing.T) {
+	g := NewWithT(t)
+
+	columns, err := ParseMetadataColumns([]string{"cost_center", "Tier:int", "on-call:bool"})
+	g.Expect(err).To(BeNil())
+	g.Expect(columns).To(Equal([]MetadataColumn{
+		{Key: "cost_center", Name: "cost_center", Type: proto.ColumnType_STRING},
+		{Key: "Tier", Name: "tier", Type: proto.ColumnType_INT},
+		{Key: "on-call", Name: "on_call", Type: proto.ColumnType_BOOL},
+	}))
+
+	for _, entry := range []string{"", ":int", "tier:float"} {
+		_, err := ParseMetadataColumns([]string{entry})
+		g.Expect(err).ToNot(BeNil())
+	}
+}
+
+func getColumn(table *plugin.Table, name string) *plugin.Column {
+	for _, column := range table.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+func TestPluginTableDefinitionsMetadataColumns(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{MetadataColumns: []string{"cost_center", "tier:int"}},
+	}
+
+	tables, err := pluginTableDefinitions(ctx, &plugin.TableMapData{Connection: connection})
+	g.Expect(err).To(BeNil())
+
+	entityColumn := getColumn(tables["cortex_entity"], "tier")
+	g.Expect(entityColumn).ToNot(BeNil())
+	g.Expect(entityColumn.Type).To(Equal(proto.ColumnType_INT))
+	entity := CortexEntityElement{Metadata: []CortexEntityElementMetadata{{Key: "tier", Value: ScalarOrMap{Scalar: 1}}}}
+	value, err := entityColumn.Transform.Execute(ctx, &transform.TransformData{HydrateItem: entity})
+	g.Expect(err).To(BeNil())
+	g.Expect(value).To(Equal(1))
+
+	teamColumn := getColumn(tables["cortex_team"], "cost_center")
+	g.Expect(teamColumn).ToNot(BeNil())
+	team := CortexTeamElement{Metadata: map[string]interface{}{"cost_center": "cc-1"}}
+	value, err = teamColumn.Transform.Execute(ctx, &transform.TransformData{HydrateItem: team})
+	g.Expect(err).To(BeNil())
+	g.Expect(value).To(Equal("cc-1"))
+
+	g.Expect(getColumn(tables["cortex_descriptor"], "tier")).To(BeNil())
+}
+
+func TestPluginTableDefinitionsMetadataColumnClash(t *testing.T) {
+	g := NewWithT(t)
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{MetadataColumns: []string{"name"}},
+	}
+
+	_, err := pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("metadata_columns entry \"name\" clashes with column name of cortex_entity"))
+}
@@ -1,5 +1,11 @@
 package cortex
 
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 type Cortex struct {
 	Openapi string     `yaml:"openapi"`
 	Info    CortexInfo `yaml:"info"`
@@ -24,6 +30,106 @@ type CortexInfo struct {
 	Dependency     CortexDependency       `yaml:"x-cortex-dependency,omitempty"`
 	SLOs           CortexSLOs             `yaml:"x-cortex-slos,omitempty"`
 	StaticAnalysis CortexStaticAnalysis   `yaml:"x-cortex-static-analysis,omitempty"`
+	Alerts         []CortexAlert          `yaml:"x-cortex-alerts,omitempty"`
+
+	// Blocks without a field above, kept so they can be queried and are sent back when validating
+	Other map[string]interface{} `yaml:",inline"`
+
+	// The info block as returned by the API, including fields the structs above don't model
+	Raw map[string]interface{} `yaml:"-"`
+}
+
+// Keep the info block as returned alongside the typed fields
+func (i *CortexInfo) UnmarshalYAML(value *yaml.Node) error {
+	type cortexInfo CortexInfo
+	if err := value.Decode((*cortexInfo)(i)); err != nil {
+		return err
+	}
+	return value.Decode(&i.Raw)
+}
+
+// The whole descriptor, the API only returns the info block so the openapi version is the one we validate against
+func (i CortexInfo) Descriptor() map[string]interface{} {
+	info := i.Raw
+	if info == nil {
+		// Built rather than decoded, the typed fields are all there is
+		if body, err := yaml.Marshal(i); err == nil {
+			_ = yaml.Unmarshal(body, &info)
+		}
+	}
+	return map[string]interface{}{"openapi": DescriptorOpenapiVersion, "info": info}
+}
+
+// The whole descriptor as YAML with sorted keys, so it can be diffed against the file in git
+func (i CortexInfo) DescriptorYAML() string {
+	var body strings.Builder
+	encoder := yaml.NewEncoder(&body)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(i.Descriptor()); err != nil {
+		return ""
+	}
+	return body.String()
+}
+
+type CortexAlert struct {
+	Type  string `yaml:"type"`
+	Tag   string `yaml:"tag"`
+	Value string `yaml:"value,omitempty"`
+}
+
+// Names of the groups, i.e. teams, listed in x-cortex-owners
+func (i CortexInfo) OwnerGroups() []string {
+	var groups []string
+	for _, owner := range i.Owners {
+		if strings.EqualFold(owner.Type, "GROUP") {
+			groups = append(groups, owner.Name)
+		}
+	}
+	return groups
+}
+
+// Emails of the individuals listed in x-cortex-owners
+func (i CortexInfo) OwnerEmails() []string {
+	var emails []string
+	for _, owner := range i.Owners {
+		if strings.EqualFold(owner.Type, "EMAIL") {
+			emails = append(emails, owner.Email)
+		}
+	}
+	return emails
+}
+
+// IDs of the SLOs from every integration
+func (i CortexInfo) SLOIDs() []string {
+	var ids []string
+	for _, slo := range i.SLOs.NewRelic {
+		ids = append(ids, slo.ID)
+	}
+	return ids
+}
+
+// The x-cortex-* blocks without a column of their own, keyed by block name
+func (i CortexInfo) Extensions() map[string]interface{} {
+	extensions := make(map[string]interface{})
+	for key, value := range i.Other {
+		if strings.HasPrefix(key, "x-cortex-") {
+			extensions[key] = value
+		}
+	}
+	return extensions
+}
+
+// Decode one of the blocks in Other, e.g. x-cortex-firehydrant, into out. Missing blocks leave out as it is.
+func (i CortexInfo) DecodeExtension(name string, out interface{}) error {
+	block, ok := i.Other[name]
+	if !ok {
+		return nil
+	}
+	body, err := yaml.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(body, out)
 }
 
 type CortexTag struct {
@@ -54,6 +160,9 @@ type CortexTeamMember struct {
 	Email                string `yaml:"email"`
 	NotificationsEnabled bool   `yaml:"notificationsEnabled"`
 	Role                 string `yaml:"role,omitempty"`
+
+	// Enriched data
+	Source string `yaml:"-"`
 }
 
 type CortexSlack struct {
@@ -83,6 +192,7 @@ type CortexGithub struct {
 
 type CortexOncall struct {
 	VictorOps CortexOncallVictorOps `yaml:"victorops"`
+	Opsgenie  CortexOncallOpsgenie  `yaml:"opsgenie,omitempty"`
 }
 
 type CortexOncallVictorOps struct {
@@ -90,6 +200,36 @@ type CortexOncallVictorOps struct {
 	ID   string `yaml:"id"`
 }
 
+type CortexOncallOpsgenie struct {
+	Type  string `yaml:"type"`
+	ID    string `yaml:"id"`
+	Alias string `yaml:"alias,omitempty"`
+}
+
+// The x-cortex-firehydrant block, kept in Other so it stays in the extensions column
+type CortexFireHydrant struct {
+	Services []CortexFireHydrantService `yaml:"services"`
+}
+
+type CortexFireHydrantService struct {
+	Identifier     string `yaml:"identifier"`
+	IdentifierType string `yaml:"identifierType"`
+	Alias          string `yaml:"alias,omitempty"`
+}
+
+// The x-cortex-incident-io block, kept in Other so it stays in the extensions column
+type CortexIncidentIO struct {
+	CustomFields []CortexIncidentIOCustomField `yaml:"customFields"`
+}
+
+// Custom fields are identified by either name or id
+type CortexIncidentIOCustomField struct {
+	Name  string `yaml:"name,omitempty"`
+	ID    string `yaml:"id,omitempty"`
+	Value string `yaml:"value"`
+	Alias string `yaml:"alias,omitempty"`
+}
+
 type CortexIssues struct {
 	Jira CortexIssuesJira `yaml:"jira"`
 }
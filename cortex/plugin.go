@@ -2,18 +2,58 @@ package cortex
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/schema"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
-const DefaultBaseURL = "https://api.getcortexapp.com"
+const PluginName = "steampipe-plugin-cortex"
+const DefaultBaseURL = cortexapi.DefaultBaseURL
+const DefaultQueryPollInterval = 2 * time.Second
+const DefaultQueryTimeout = 5 * time.Minute
+const DefaultRetryBudget = 10
+const DefaultScanTimeout = 10 * time.Minute
+const DefaultMaxParallelGets = 10
+const DefaultMaxConcurrentRequests = 20
+const DefaultSchemaRefreshInterval = 15 * time.Minute
+
+// Deploys and GitOps syncs are usually queried to see what just happened, a cached result is misleading
+var DefaultUncachedTables = []string{"cortex_entity_event", "cortex_gitops_log"}
+
+// Per-row hydrate calls each make API requests, limit how many run at once so large scans don't flood the API
+const MaxHydrateConcurrency = 10
 
 type SteampipeConfig struct {
-	ApiKey  *string `cty:"api_key"`
-	BaseURL *string `cty:"base_url"`
+	ApiKey                *string  `cty:"api_key"`
+	ApiKeys               []string `cty:"api_keys"`
+	BaseURL               *string  `cty:"base_url"`
+	QueryPollInterval     *string  `cty:"query_poll_interval"`
+	QueryTimeout          *string  `cty:"query_timeout"`
+	RetryBudget           *int     `cty:"retry_budget"`
+	ScanTimeout           *string  `cty:"scan_timeout"`
+	MetadataColumns       []string `cty:"metadata_columns"`
+	MaxParallelGets       *int     `cty:"max_parallel_gets"`
+	UncachedTables        []string `cty:"uncached_tables"`
+	TableTimeouts         []string `cty:"table_timeouts"`
+	MaxConcurrentRequests *int     `cty:"max_concurrent_requests"`
+	IgnoreRowErrors       *bool    `cty:"ignore_row_errors"`
+	SchemaRefreshInterval *string  `cty:"schema_refresh_interval"`
+	NullEmptyCollections  *bool    `cty:"null_empty_collections"`
+	IgnoreErrorCodes      []int    `cty:"ignore_error_codes"`
+	Source                *string  `cty:"source"`
+	FixturesDir           *string  `cty:"fixtures_dir"`
+
+	// Set by GetConfig, statistics such as cortex_query_diagnostics are kept per connection
+	connection string
+	// Set by GetTableConfig, the list and per-row hydrate clients of a scan share its retry budget
+	scan *plugin.QueryContext
 }
 
 func NewSteampipeConfig(token, url string) *SteampipeConfig {
@@ -26,11 +66,13 @@ func GetConfig(connection *plugin.Connection) *SteampipeConfig {
 	}
 	// Even though we return a ptr, steampipe code calls helpers.DereferencePointer
 	config, _ := connection.Config.(SteampipeConfig)
+	config.connection = connection.Name
 
-	// Read the API key from the environment and override the value in the config
+	// Read the API key from the environment and override the values in the config
 	token, ok := os.LookupEnv("CORTEX_API_KEY")
 	if ok {
 		config.ApiKey = &token
+		config.ApiKeys = nil
 	}
 
 	// Read the base URL from the environment and override the value in the config
@@ -42,24 +84,296 @@ func GetConfig(connection *plugin.Connection) *SteampipeConfig {
 	return &config
 }
 
+// Config for a table scan, the scan timeout is replaced by the table's table_timeouts entry if it has one
+func GetTableConfig(d *plugin.QueryData) *SteampipeConfig {
+	config := GetConfig(d.Connection)
+	// Invalid entries are reported when the tables are defined
+	timeouts, _ := ParseTableTimeouts(config.TableTimeouts)
+	if timeout, ok := timeouts[d.Table.Name]; ok {
+		scanTimeout := timeout.String()
+		config.ScanTimeout = &scanTimeout
+	}
+	// Steampipe passes the same query context to every hydrate call of a scan
+	config.scan = d.QueryContext
+	return config
+}
+
+// The connection statistics are kept for, the base URL when there is no connection, e.g. in tests
+func (c *SteampipeConfig) connectionKey() string {
+	if c.connection != "" {
+		return c.connection
+	}
+	return *c.BaseURL
+}
+
+// The keys requests are rotated across, api_keys if set, otherwise api_key
+func (c *SteampipeConfig) GetApiKeys() []string {
+	if len(c.ApiKeys) > 0 {
+		return c.ApiKeys
+	}
+	if c.ApiKey == nil || *c.ApiKey == "" {
+		return nil
+	}
+	return []string{*c.ApiKey}
+}
+
+// How often to check on a submitted CQL query, e.g. "2s"
+func (c *SteampipeConfig) GetQueryPollInterval() time.Duration {
+	return parseDurationOrDefault(c.QueryPollInterval, DefaultQueryPollInterval)
+}
+
+// How long to wait for a submitted CQL query before giving up, e.g. "5m"
+func (c *SteampipeConfig) GetQueryTimeout() time.Duration {
+	return parseDurationOrDefault(c.QueryTimeout, DefaultQueryTimeout)
+}
+
+// How many retries all API calls of one table scan may share, e.g. 10
+func (c *SteampipeConfig) GetRetryBudget() int {
+	if c.RetryBudget == nil || *c.RetryBudget < 0 {
+		return DefaultRetryBudget
+	}
+	return *c.RetryBudget
+}
+
+// How long all API calls of one table scan may take before giving up, e.g. "10m"
+func (c *SteampipeConfig) GetScanTimeout() time.Duration {
+	return parseDurationOrDefault(c.ScanTimeout, DefaultScanTimeout)
+}
+
+// How many entities looked up by tag, e.g. with tag IN (...), may be fetched at once
+func (c *SteampipeConfig) GetMaxParallelGets() int {
+	if c.MaxParallelGets == nil || *c.MaxParallelGets < 1 {
+		return DefaultMaxParallelGets
+	}
+	return *c.MaxParallelGets
+}
+
+// How many calls to the Cortex API may be in flight at once across all scans, e.g. 20
+func (c *SteampipeConfig) GetMaxConcurrentRequests() int {
+	if c.MaxConcurrentRequests == nil || *c.MaxConcurrentRequests < 1 {
+		return DefaultMaxConcurrentRequests
+	}
+	return *c.MaxConcurrentRequests
+}
+
+// Whether a failed per-row hydrate, e.g. effective_owners, sets the error column instead of failing the scan
+func (c *SteampipeConfig) GetIgnoreRowErrors() bool {
+	return c.IgnoreRowErrors != nil && *c.IgnoreRowErrors
+}
+
+// How often the custom entity types are fetched again to add or remove their tables, e.g. "15m". "0" turns refreshing off.
+func (c *SteampipeConfig) GetSchemaRefreshInterval() time.Duration {
+	if c.SchemaRefreshInterval != nil {
+		if duration, err := time.ParseDuration(*c.SchemaRefreshInterval); err == nil && duration == 0 {
+			return 0
+		}
+	}
+	return parseDurationOrDefault(c.SchemaRefreshInterval, DefaultSchemaRefreshInterval)
+}
+
+// Whether absent or empty arrays and objects in JSON columns are SQL NULL rather than [] or {}
+func (c *SteampipeConfig) GetNullEmptyCollections() bool {
+	return c.NullEmptyCollections != nil && *c.NullEmptyCollections
+}
+
+// Where table data is read from, "api" for the Cortex API or "file" for the fixtures in fixtures_dir
+func (c *SteampipeConfig) GetSource() string {
+	if c.Source == nil || *c.Source == "" {
+		return SourceAPI
+	}
+	return *c.Source
+}
+
+// Tables whose results are never cached, e.g. ["cortex_entity_event"]. An empty list caches every table.
+func (c *SteampipeConfig) GetUncachedTables() []string {
+	if c.UncachedTables == nil {
+		return DefaultUncachedTables
+	}
+	return c.UncachedTables
+}
+
+// Parse table_timeouts entries such as "cortex_query:20m" into the scan timeout of each table
+func ParseTableTimeouts(entries []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		table, value, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("table_timeouts entry %q should be \"table:duration\"", entry)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("table_timeouts entry %q has an invalid duration", entry)
+		}
+		timeouts[table] = timeout
+	}
+	return timeouts, nil
+}
+
+func parseDurationOrDefault(value *string, defaultValue time.Duration) time.Duration {
+	if value == nil {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(*value)
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
+
 func Plugin(ctx context.Context) *plugin.Plugin {
 	p := &plugin.Plugin{
-		Name:             "steampipe-plugin-cortex",
+		Name:             PluginName,
 		DefaultTransform: transform.FromGo().NullIfZero(),
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreErrors,
+		},
 		ConnectionConfigSchema: &plugin.ConnectionConfigSchema{
 			NewInstance: func() interface{} {
 				return NewSteampipeConfig("", DefaultBaseURL)
 			},
 			Schema: map[string]*schema.Attribute{
-				"api_key": {Type: schema.TypeString},
+				"api_key":                 {Type: schema.TypeString},
+				"api_keys":                {Type: schema.TypeList, Elem: &schema.Attribute{Type: schema.TypeString}},
+				"base_url":                {Type: schema.TypeString},
+				"query_poll_interval":     {Type: schema.TypeString},
+				"query_timeout":           {Type: schema.TypeString},
+				"retry_budget":            {Type: schema.TypeInt},
+				"scan_timeout":            {Type: schema.TypeString},
+				"metadata_columns":        {Type: schema.TypeList, Elem: &schema.Attribute{Type: schema.TypeString}},
+				"max_parallel_gets":       {Type: schema.TypeInt},
+				"uncached_tables":         {Type: schema.TypeList, Elem: &schema.Attribute{Type: schema.TypeString}},
+				"table_timeouts":          {Type: schema.TypeList, Elem: &schema.Attribute{Type: schema.TypeString}},
+				"max_concurrent_requests": {Type: schema.TypeInt},
+				"ignore_row_errors":       {Type: schema.TypeBool},
+				"schema_refresh_interval": {Type: schema.TypeString},
+				"null_empty_collections":  {Type: schema.TypeBool},
+				"ignore_error_codes":      {Type: schema.TypeList, Elem: &schema.Attribute{Type: schema.TypeInt}},
+				"source":                  {Type: schema.TypeString},
+				"fixtures_dir":            {Type: schema.TypeString},
 			},
 		},
-		TableMap: map[string]*plugin.Table{
-			"cortex_descriptor":      tableCortexDescriptor(),
-			"cortex_entity":          tableCortexEntity(),
-			"cortex_team":            tableCortexTeam(),
-			"cortex_scorecard_score": tableCortexScorecardScore(),
-		},
+		SchemaMode: plugin.SchemaModeDynamic,
+	}
+	// Rebuild the schema of a connection every refresh interval so new custom entity types get tables
+	p.TableMapFunc = func(ctx context.Context, d *plugin.TableMapData) (map[string]*plugin.Table, error) {
+		tables, err := pluginTableDefinitions(ctx, d)
+		if err == nil && d.Connection != nil {
+			connection := d.Connection
+			scheduleSchemaRefresh(connection.Name, GetConfig(connection).GetSchemaRefreshInterval(), func() {
+				if err := p.ConnectionSchemaChanged(connection); err != nil {
+					p.Logger.Warn("schemaRefresh", "connection", connection.Name, "Error", err)
+				}
+			})
+		}
+		return tables, err
 	}
 	return p
 }
+
+// Tables depend on the connection config, metadata_columns adds columns to the entity and team tables
+// and uncached_tables disables caching of tables. null_empty_collections sets how JSON columns without a value are returned.
+// table_timeouts is validated here and applied by GetTableConfig.
+// Custom entity types each get a table, so the tables also depend on the workspace, or the fixtures with source "file"
+func pluginTableDefinitions(ctx context.Context, d *plugin.TableMapData) (map[string]*plugin.Table, error) {
+	config := GetConfig(d.Connection)
+	switch config.GetSource() {
+	case SourceAPI:
+	case SourceFile:
+		if config.FixturesDir == nil || *config.FixturesDir == "" {
+			return nil, fmt.Errorf("source %q needs fixtures_dir to be set", SourceFile)
+		}
+	default:
+		return nil, fmt.Errorf("source should be %q or %q, got %q", SourceAPI, SourceFile, config.GetSource())
+	}
+
+	metadataColumns, err := ParseMetadataColumns(config.MetadataColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := tableCortexEntity()
+	err = addMetadataColumns(entity, metadataColumns, func() *transform.ColumnTransforms {
+		return transform.FromField("Metadata").Transform(TagArrayToMap)
+	})
+	if err != nil {
+		return nil, err
+	}
+	team := tableCortexTeam()
+	err = addMetadataColumns(team, metadataColumns, func() *transform.ColumnTransforms {
+		return transform.FromField("Metadata")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tables := map[string]*plugin.Table{
+		"cortex_deploy_summary":              tableCortexDeploySummary(),
+		"cortex_descriptor":                  tableCortexDescriptor(),
+		"cortex_entity":                      entity,
+		"cortex_entity_event":                tableCortexEntityEvent(),
+		"cortex_entity_incident_integration": tableCortexEntityIncidentIntegration(),
+		"cortex_entity_link":                 tableCortexEntityLink(),
+		"cortex_entity_metadata":             tableCortexEntityMetadata(),
+		"cortex_entity_relationship":         tableCortexEntityRelationship(),
+		"cortex_entity_tech_doc":             tableCortexEntityTechDoc(),
+		"cortex_entity_type":                 tableCortexEntityType(),
+		"cortex_gitops_log":                  tableCortexGitopsLog(),
+		"cortex_ip_allowlist_validation":     tableCortexIPAllowlistValidation(),
+		"cortex_query":                       tableCortexQuery(),
+		"cortex_query_diagnostics":           tableCortexQueryDiagnostics(),
+		"cortex_rate_limit":                  tableCortexRateLimit(),
+		"cortex_team":                        team,
+		"cortex_team_hierarchy":              tableCortexTeamHierarchy(),
+		"cortex_team_link":                   tableCortexTeamLink(),
+		"cortex_team_scorecard_gap":          tableCortexTeamScorecardGap(),
+		"cortex_team_scorecard_summary":      tableCortexTeamScorecardSummary(),
+		"cortex_scim_group":                  tableCortexSCIMGroup(),
+		"cortex_scorecard_badge":             tableCortexScorecardBadge(),
+		"cortex_scorecard_score":             tableCortexScorecardScore(),
+		"cortex_scorecard_compliance":        tableCortexScorecardCompliance(),
+		"cortex_scorecard_ladder_level":      tableCortexScorecardLadderLevel(),
+		"cortex_workflow_action":             tableCortexWorkflowAction(),
+	}
+	for name, table := range customEntityTables(ctx, config, tables) {
+		tables[name] = table
+	}
+
+	// Steampipe sets one cache TTL for the whole plugin, tables with fast changing data opt out of caching
+	for _, name := range config.GetUncachedTables() {
+		table, ok := tables[name]
+		if !ok {
+			return nil, fmt.Errorf("uncached_tables: unknown table %q", name)
+		}
+		table.Cache = &plugin.TableCacheOptions{Enabled: false}
+	}
+
+	// Absent collections are otherwise a mix of NULL and JSON null depending on the column's transform
+	collections := NullCollectionToEmpty
+	if config.GetNullEmptyCollections() {
+		collections = EmptyCollectionToNull
+	}
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			if column.Type != proto.ColumnType_JSON {
+				continue
+			}
+			if column.Transform == nil {
+				// The default transform turns nil into an untyped NULL, so use the field as is
+				column.Transform = transform.FromGo()
+			}
+			column.Transform = column.Transform.Transform(collections)
+		}
+	}
+
+	// Timeouts are applied when a table is scanned, check they are valid up front
+	timeouts, err := ParseTableTimeouts(config.TableTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	for name := range timeouts {
+		if _, ok := tables[name]; !ok {
+			return nil, fmt.Errorf("table_timeouts: unknown table %q", name)
+		}
+	}
+	return tables, nil
+}
@@ -1,11 +1,15 @@
 package cortex
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
 	_ "unsafe"
 
 	. "github.com/onsi/gomega"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -38,3 +42,160 @@ func TestGetConfigWithEnvVars(t *testing.T) {
 	g.Expect(*config.ApiKey).To(Equal("env_api_key"))
 	g.Expect(*config.BaseURL).To(Equal("https://env-url.com"))
 }
+
+// Options missing from the schema are rejected by steampipe when the connection is parsed
+func TestConnectionConfigSchema(t *testing.T) {
+	g := NewWithT(t)
+	configSchema := Plugin(context.Background()).ConnectionConfigSchema.Schema
+
+	fields := reflect.TypeOf(SteampipeConfig{})
+	options := 0
+	for i := 0; i < fields.NumField(); i++ {
+		option, ok := fields.Field(i).Tag.Lookup("cty")
+		if !ok {
+			continue
+		}
+		options++
+		g.Expect(configSchema).To(HaveKey(option))
+	}
+	g.Expect(configSchema).To(HaveLen(options))
+}
+
+func TestGetConfigQueryDurations(t *testing.T) {
+	g := NewWithT(t)
+	pollInterval := "10s"
+	timeout := "not a duration"
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{
+			QueryPollInterval: &pollInterval,
+			QueryTimeout:      &timeout,
+		},
+	}
+
+	config := GetConfig(connection)
+
+	g.Expect(config.GetQueryPollInterval()).To(Equal(10 * time.Second))
+	g.Expect(config.GetQueryTimeout()).To(Equal(DefaultQueryTimeout))
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetQueryPollInterval()).To(Equal(DefaultQueryPollInterval))
+}
+
+func TestGetConfigRetryBudget(t *testing.T) {
+	g := NewWithT(t)
+	retryBudget := 3
+	scanTimeout := "1m"
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{
+			RetryBudget: &retryBudget,
+			ScanTimeout: &scanTimeout,
+		},
+	}
+
+	config := GetConfig(connection)
+
+	g.Expect(config.GetRetryBudget()).To(Equal(3))
+	g.Expect(config.GetScanTimeout()).To(Equal(time.Minute))
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetRetryBudget()).To(Equal(DefaultRetryBudget))
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetScanTimeout()).To(Equal(DefaultScanTimeout))
+}
+
+func TestGetConfigMaxParallelGets(t *testing.T) {
+	g := NewWithT(t)
+	maxParallelGets := 4
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{MaxParallelGets: &maxParallelGets},
+	}
+
+	g.Expect(GetConfig(connection).GetMaxParallelGets()).To(Equal(4))
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetMaxParallelGets()).To(Equal(DefaultMaxParallelGets))
+}
+
+func TestGetConfigMaxConcurrentRequests(t *testing.T) {
+	g := NewWithT(t)
+	maxConcurrentRequests := 5
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{MaxConcurrentRequests: &maxConcurrentRequests},
+	}
+
+	g.Expect(GetConfig(connection).GetMaxConcurrentRequests()).To(Equal(5))
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetMaxConcurrentRequests()).To(Equal(DefaultMaxConcurrentRequests))
+}
+
+func TestPluginTableDefinitionsUncachedTables(t *testing.T) {
+	g := NewWithT(t)
+
+	// Defaults
+	tables, err := pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: &plugin.Connection{Config: SteampipeConfig{}}})
+	g.Expect(err).To(BeNil())
+	g.Expect(tables["cortex_entity_event"].Cache).To(Equal(&plugin.TableCacheOptions{Enabled: false}))
+	g.Expect(tables["cortex_entity"].Cache).To(BeNil())
+
+	// Overridden, an empty list caches every table
+	connection := &plugin.Connection{Config: SteampipeConfig{UncachedTables: []string{}}}
+	tables, err = pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).To(BeNil())
+	g.Expect(tables["cortex_entity_event"].Cache).To(BeNil())
+
+	connection = &plugin.Connection{Config: SteampipeConfig{UncachedTables: []string{"cortex_nope"}}}
+	_, err = pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("uncached_tables: unknown table \"cortex_nope\""))
+}
+
+func TestPluginTableDefinitionsNullEmptyCollections(t *testing.T) {
+	g := NewWithT(t)
+	groups := func(config SteampipeConfig, entity CortexEntityElement) interface{} {
+		tables, err := pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: &plugin.Connection{Config: config}})
+		g.Expect(err).To(BeNil())
+		value, err := getColumn(tables["cortex_entity"], "groups").Transform.Execute(context.Background(), &transform.TransformData{HydrateItem: entity, ColumnName: "groups"})
+		g.Expect(err).To(BeNil())
+		return value
+	}
+
+	// Defaults to empty collections
+	g.Expect(groups(SteampipeConfig{}, CortexEntityElement{})).To(Equal([]interface{}{}))
+	g.Expect(groups(SteampipeConfig{}, CortexEntityElement{Groups: []string{"a"}})).To(Equal([]string{"a"}))
+
+	null := true
+	g.Expect(groups(SteampipeConfig{NullEmptyCollections: &null}, CortexEntityElement{})).To(BeNil())
+	g.Expect(groups(SteampipeConfig{NullEmptyCollections: &null}, CortexEntityElement{Groups: []string{}})).To(BeNil())
+	g.Expect(groups(SteampipeConfig{NullEmptyCollections: &null}, CortexEntityElement{Groups: []string{"a"}})).To(Equal([]string{"a"}))
+}
+
+func TestGetTableConfigTimeouts(t *testing.T) {
+	g := NewWithT(t)
+	connection := &plugin.Connection{
+		Config: SteampipeConfig{TableTimeouts: []string{"cortex_query:20m"}},
+	}
+
+	config := GetTableConfig(&plugin.QueryData{Table: &plugin.Table{Name: "cortex_query"}, Connection: connection})
+	g.Expect(config.GetScanTimeout()).To(Equal(20 * time.Minute))
+	config = GetTableConfig(&plugin.QueryData{Table: &plugin.Table{Name: "cortex_entity"}, Connection: connection})
+	g.Expect(config.GetScanTimeout()).To(Equal(DefaultScanTimeout))
+
+	// The scan is identified by its query context, so its clients share one retry budget
+	scan := &plugin.QueryContext{}
+	config = GetTableConfig(&plugin.QueryData{Table: &plugin.Table{Name: "cortex_entity"}, Connection: connection, QueryContext: scan})
+	g.Expect(config.scan).To(BeIdenticalTo(scan))
+
+	_, err := ParseTableTimeouts([]string{"cortex_query"})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("table_timeouts entry \"cortex_query\" should be \"table:duration\""))
+	_, err = ParseTableTimeouts([]string{"cortex_query:soon"})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("table_timeouts entry \"cortex_query:soon\" has an invalid duration"))
+
+	connection = &plugin.Connection{Config: SteampipeConfig{TableTimeouts: []string{"cortex_nope:1m"}}}
+	_, err = pluginTableDefinitions(context.Background(), &plugin.TableMapData{Connection: connection})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("table_timeouts: unknown table \"cortex_nope\""))
+}
+
+func TestGetConfigSchemaRefreshInterval(t *testing.T) {
+	g := NewWithT(t)
+	off := "0"
+	hourly := "1h"
+
+	g.Expect(NewSteampipeConfig("", DefaultBaseURL).GetSchemaRefreshInterval()).To(Equal(DefaultSchemaRefreshInterval))
+	g.Expect((&SteampipeConfig{SchemaRefreshInterval: &off}).GetSchemaRefreshInterval()).To(Equal(time.Duration(0)))
+	g.Expect((&SteampipeConfig{SchemaRefreshInterval: &hourly}).GetSchemaRefreshInterval()).To(Equal(time.Hour))
+}
@@ -0,0 +1,203 @@
+package cortex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// Status of a CQL query job
+const (
+	QueryStatusQueued    = "QUEUED"
+	QueryStatusRunning   = "RUNNING"
+	QueryStatusDone      = "DONE"
+	QueryStatusFailed    = "FAILED"
+	QueryStatusCancelled = "CANCELLED"
+	QueryStatusTimedOut  = "TIMEDOUT"
+)
+
+// Response elements for the /queries and /queries/{jobId} endpoints
+type CortexQueryJob struct {
+	JobID  string                  `yaml:"jobId"`
+	Status string                  `yaml:"status"`
+	Result []CortexQueryResultItem `yaml:"result"`
+}
+
+type CortexQueryResultItem struct {
+	Tag    string      `yaml:"tag"`
+	Name   string      `yaml:"name"`
+	Type   string      `yaml:"type"`
+	Result interface{} `yaml:"result"`
+}
+
+// Jobs submitted for each query of a connection. Running a query again, e.g. when a scan is retried
+// after timing out, polls the job still running for it rather than submitting the work twice.
+// Entries are removed when the job finishes and expire after twice the query timeout, so a job
+// left behind by an abandoned scan is not polled for results long after it was submitted.
+var submittedQueries = struct {
+	sync.Mutex
+	byQuery map[string]*submittedQuery
+}{byQuery: make(map[string]*submittedQuery)}
+
+type submittedQuery struct {
+	key     string
+	expires time.Time
+
+	mu    sync.Mutex
+	jobID string
+}
+
+func getSubmittedQuery(baseURL string, query string, timeout time.Duration) *submittedQuery {
+	submittedQueries.Lock()
+	defer submittedQueries.Unlock()
+	now := time.Now()
+	for key, submitted := range submittedQueries.byQuery {
+		if now.After(submitted.expires) {
+			delete(submittedQueries.byQuery, key)
+		}
+	}
+	key := baseURL + "\x00" + query
+	submitted, ok := submittedQueries.byQuery[key]
+	if !ok {
+		submitted = &submittedQuery{key: key, expires: now.Add(2 * timeout)}
+		submittedQueries.byQuery[key] = submitted
+	}
+	return submitted
+}
+
+// Get the job still running for the query, or submit it if there is none.
+// Concurrent scans of the same query wait for the first submit.
+func (s *submittedQuery) job(ctx context.Context, client *req.Client, query string) (*CortexQueryJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobID != "" && time.Now().Before(s.expires) {
+		job, err := getQuery(ctx, client, s.jobID)
+		var apiErr *cortexapi.Error
+		if err == nil || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return job, err
+		}
+		// Cortex no longer has the job
+		s.jobID = ""
+	}
+	job, err := submitQuery(ctx, client, query)
+	if err != nil {
+		return nil, err
+	}
+	s.jobID = job.JobID
+	return job, nil
+}
+
+// Forget a finished job, so the next run of the query submits it again
+func (s *submittedQuery) finish(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobID != jobID {
+		return
+	}
+	s.jobID = ""
+	submittedQueries.Lock()
+	defer submittedQueries.Unlock()
+	if submittedQueries.byQuery[s.key] == s {
+		delete(submittedQueries.byQuery, s.key)
+	}
+}
+
+// Submit a CQL query and poll until it completes, fails or the timeout is reached.
+// The Queries API is asynchronous so the submit only returns a job id.
+// The submit is never retried, a job still running for the same query is polled instead.
+func RunQuery(ctx context.Context, client *req.Client, query string, pollInterval time.Duration, timeout time.Duration) (*CortexQueryJob, error) {
+	logger := plugin.Logger(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	submitted := getSubmittedQuery(client.BaseURL, query, timeout)
+	job, err := submitted.job(ctx, client, query)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("RunQuery", "jobId", job.JobID, "status", job.Status)
+
+	for {
+		switch job.Status {
+		case QueryStatusDone:
+			submitted.finish(job.JobID)
+			return job, nil
+		case QueryStatusFailed, QueryStatusCancelled, QueryStatusTimedOut:
+			submitted.finish(job.JobID)
+			return nil, fmt.Errorf("cortex query %s finished with status %s", job.JobID, job.Status)
+		}
+
+		// Wait before polling again, stop early if the timeout is reached
+		select {
+		case <-ctx.Done():
+			// The scan itself may have been cancelled, e.g. when its limit was hit
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("cortex query %s did not complete within %s: %w", job.JobID, timeout, ctx.Err())
+			}
+			return nil, fmt.Errorf("cortex query %s was cancelled before it completed: %w", job.JobID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+
+		job, err = getQuery(ctx, client, job.JobID)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("RunQuery", "jobId", job.JobID, "status", job.Status)
+	}
+}
+
+func submitQuery(ctx context.Context, client *req.Client, query string) (*CortexQueryJob, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Post("/api/v1/queries").
+		SetBody(map[string]string{"query": query}).
+		Do(ctx)
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("submitQuery", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var job CortexQueryJob
+	err := resp.Into(&job)
+	if err != nil {
+		logger.Error("submitQuery", "Error", err)
+		return nil, err
+	}
+	return &job, nil
+}
+
+func getQuery(ctx context.Context, client *req.Client, jobID string) (*CortexQueryJob, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/queries/{jobId}").
+		SetPathParam("jobId", jobID).
+		Do(ctx)
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getQuery", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var job CortexQueryJob
+	err := resp.Into(&job)
+	if err != nil {
+		logger.Error("getQuery", "Error", err)
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,227 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"gopkg.in/yaml.v3"
+)
+
+func prepareQueryJobResponse(t *testing.T, job CortexQueryJob) []byte {
+	t.Helper()
+	responseBytes, err := yaml.Marshal(job)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func TestRunQueryPollsUntilDone(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.VerifyJSON(`{"query": "git != null"}`),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusQueued}), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/queries/job1"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusRunning}), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/queries/job1"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{
+				JobID:  "job1",
+				Status: QueryStatusDone,
+				Result: []CortexQueryResultItem{{Tag: "service1", Result: true}},
+			}), nil),
+		),
+	)
+	defer server.Close()
+
+	job, err := RunQuery(ctx, client, "git != null", time.Millisecond, time.Second)
+	g.Expect(err).To(BeNil())
+	g.Expect(job.Status).To(Equal(QueryStatusDone))
+	g.Expect(job.Result).To(HaveLen(1))
+	g.Expect(job.Result[0].Tag).To(Equal("service1"))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(3))
+}
+
+func TestRunQueryFailed(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusFailed}), nil),
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "not cql", time.Millisecond, time.Second)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("cortex query job1 finished with status FAILED"))
+}
+
+func TestRunQueryTimeout(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusQueued}), nil),
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "git != null", time.Second, 10*time.Millisecond)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("cortex query job1 did not complete within 10ms: context deadline exceeded"))
+}
+
+func TestRunQueryCancelled(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusQueued}), nil),
+		),
+	)
+	defer server.Close()
+
+	// The scan is cancelled while waiting to poll, e.g. its limit was hit, which isn't a timeout
+	ctx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := RunQuery(ctx, client, "jira != null", time.Second, time.Minute)
+	g.Expect(err).To(MatchError(context.Canceled))
+	g.Expect(err.Error()).To(Equal("cortex query job1 was cancelled before it completed: context canceled"))
+}
+
+func TestRunQuerySubmitError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusBadRequest, "{\"details\": \"fake error on submit\"}", nil),
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "not cql", time.Millisecond, time.Second)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 400 Bad Request: {\"details\": \"fake error on submit\"}"))
+}
+
+// Close the connection without a response, as if the network failed
+func dropConnection(w http.ResponseWriter, r *http.Request) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestRunQuerySubmitIsNotRetried(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			dropConnection,
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "git != null", time.Millisecond, time.Second)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(server.ReceivedRequests()).To(HaveLen(1))
+}
+
+func TestRunQueryPollsRunningJob(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusQueued}), nil),
+		),
+		// Running the query again polls the job instead of submitting it twice
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/queries/job1"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusDone}), nil),
+		),
+		// The job finished, so the next run submits the query
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job2", Status: QueryStatusQueued}), nil),
+		),
+		// Cortex no longer has the job, so it is submitted again
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/queries/job2"),
+			gh.RespondWith(http.StatusNotFound, "{}", nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job3", Status: QueryStatusDone}), nil),
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "git != null", time.Second, 100*time.Millisecond)
+	g.Expect(err).ToNot(BeNil())
+	job, err := RunQuery(ctx, client, "git != null", time.Millisecond, time.Second)
+	g.Expect(err).To(BeNil())
+	g.Expect(job.JobID).To(Equal("job1"))
+
+	_, err = RunQuery(ctx, client, "git != null", time.Second, 100*time.Millisecond)
+	g.Expect(err).ToNot(BeNil())
+	job, err = RunQuery(ctx, client, "git != null", time.Millisecond, time.Second)
+	g.Expect(err).To(BeNil())
+	g.Expect(job.JobID).To(Equal("job3"))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(5))
+
+	// Finished jobs are removed from the registry
+	submittedQueries.Lock()
+	defer submittedQueries.Unlock()
+	g.Expect(submittedQueries.byQuery).ToNot(HaveKey(client.BaseURL + "\x00git != null"))
+}
+
+func TestRunQueryExpiredJobIsNotPolled(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job1", Status: QueryStatusQueued}), nil),
+		),
+		// The job of the abandoned run is too old to poll, so the query is submitted again
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{JobID: "job2", Status: QueryStatusDone}), nil),
+		),
+	)
+	defer server.Close()
+
+	_, err := RunQuery(ctx, client, "custom-data != null", time.Second, 10*time.Millisecond)
+	g.Expect(err).ToNot(BeNil())
+	time.Sleep(20 * time.Millisecond)
+	job, err := RunQuery(ctx, client, "custom-data != null", time.Millisecond, time.Second)
+	g.Expect(err).To(BeNil())
+	g.Expect(job.JobID).To(Equal("job2"))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(2))
+}
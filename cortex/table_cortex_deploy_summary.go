@@ -0,0 +1,147 @@
+package cortex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Sizes of the time buckets deploys are counted in
+const (
+	DeployBucketDay   = "day"
+	DeployBucketWeek  = "week"
+	DeployBucketMonth = "month"
+)
+
+// Types reported for deploys that are counted
+const (
+	DeployTypeDeploy   = "DEPLOY"
+	DeployTypeRollback = "ROLLBACK"
+)
+
+// Used to represent the data we want to return in the table
+type CortexDeploySummaryRow struct {
+	EntityTag     string
+	Bucket        string
+	BucketStart   time.Time
+	Environment   string
+	DeployCount   int
+	RollbackCount int
+}
+
+func tableCortexDeploySummary() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_deploy_summary",
+		Description: "Cortex deploys of an entity counted per environment and time bucket.",
+		List: &plugin.ListConfig{
+			Hydrate: listDeploySummaryHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "entity_tag", Require: plugin.Required},
+				{Name: "bucket", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "bucket", Type: proto.ColumnType_STRING, Description: "Size of the time bucket, day, week or month. Defaults to week."},
+			{Name: "bucket_start", Type: proto.ColumnType_TIMESTAMP, Description: "Start of the time bucket in UTC, weeks start on Monday."},
+			{Name: "environment", Type: proto.ColumnType_STRING, Description: "Environment of the deploys."},
+			{Name: "deploy_count", Type: proto.ColumnType_INT, Description: "Number of deploys.", Transform: transform.FromField("DeployCount")},
+			{Name: "rollback_count", Type: proto.ColumnType_INT, Description: "Number of rollbacks.", Transform: transform.FromField("RollbackCount")},
+		},
+	}
+}
+
+func listDeploySummaryHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	entityTag := d.EqualsQuals["entity_tag"].GetStringValue()
+	bucket := DeployBucketWeek
+	if d.EqualsQuals["bucket"] != nil {
+		bucket = d.EqualsQuals["bucket"].GetStringValue()
+	}
+	logger.Info("listDeploySummaryHydrator", "entityTag", entityTag, "bucket", bucket)
+	return nil, listDeploySummary(ctx, client, &writer, entityTag, bucket)
+}
+
+func listDeploySummary(ctx context.Context, client *req.Client, writer HydratorWriter, entityTag string, bucket string) error {
+	if bucket != DeployBucketDay && bucket != DeployBucketWeek && bucket != DeployBucketMonth {
+		return fmt.Errorf("bucket should be %s, %s or %s, got %q", DeployBucketDay, DeployBucketWeek, DeployBucketMonth, bucket)
+	}
+
+	deploys, err := getDeploys(ctx, client, entityTag)
+	if err != nil {
+		return err
+	}
+
+	// Count the deploys of each environment in each bucket, deploys without a timestamp can't be bucketed
+	type key struct {
+		start       time.Time
+		environment string
+	}
+	counts := make(map[key]*CortexDeploySummaryRow)
+	for _, deploy := range deploys {
+		// Other types, e.g. RESTART, are neither counted nor make a row of their own
+		if deploy.Type != DeployTypeDeploy && deploy.Type != DeployTypeRollback {
+			continue
+		}
+		timestamp, err := ParseCortexTime(deploy.Timestamp)
+		if err != nil {
+			continue
+		}
+		k := key{deployBucketStart(timestamp, bucket), deploy.Environment}
+		row, ok := counts[k]
+		if !ok {
+			row = &CortexDeploySummaryRow{EntityTag: entityTag, Bucket: bucket, BucketStart: k.start, Environment: k.environment}
+			counts[k] = row
+		}
+		switch deploy.Type {
+		case DeployTypeDeploy:
+			row.DeployCount++
+		case DeployTypeRollback:
+			row.RollbackCount++
+		}
+	}
+
+	// Newest buckets first
+	rows := make([]*CortexDeploySummaryRow, 0, len(counts))
+	for _, row := range counts {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].BucketStart.Equal(rows[j].BucketStart) {
+			return rows[i].BucketStart.After(rows[j].BucketStart)
+		}
+		return rows[i].Environment < rows[j].Environment
+	})
+
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, *row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Start of the bucket the UTC time is in
+func deployBucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case DeployBucketWeek:
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case DeployBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
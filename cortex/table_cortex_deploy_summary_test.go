@@ -0,0 +1,109 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexDeploySummary(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexDeploySummary()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_deploy_summary"))
+	g.Expect(table.Description).To(Equal("Cortex deploys of an entity counted per environment and time bucket."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(2))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("entity_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("bucket"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"entity_tag", proto.ColumnType_STRING},
+		{"bucket", proto.ColumnType_STRING},
+		{"bucket_start", proto.ColumnType_TIMESTAMP},
+		{"environment", proto.ColumnType_STRING},
+		{"deploy_count", proto.ColumnType_INT},
+		{"rollback_count", proto.ColumnType_INT},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListDeploySummary(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareDeployResponse(t, []CortexDeploy{
+		{Type: "DEPLOY", Environment: "prod", Timestamp: "2025-05-05T10:00:00Z"},
+		{Type: "ROLLBACK", Environment: "prod", Timestamp: "2025-05-07T10:00:00Z"},
+		{Type: "DEPLOY", Environment: "staging", Timestamp: "2025-05-06T10:00:00Z"},
+		{Type: "DEPLOY", Environment: "prod", Timestamp: "2025-05-12T10:00:00Z"},
+		{Type: "RESTART", Environment: "prod", Timestamp: "2025-05-12T11:00:00Z"},
+		{Type: "DEPLOY", Environment: "prod", Timestamp: "not a time"},
+		// No row for an environment with only other types of deploy
+		{Type: "RESTART", Environment: "staging", Timestamp: "2025-05-13T10:00:00Z"},
+	}, 0, 1, 7)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1/deploys"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexDeploySummaryRow](100)
+
+	err := listDeploySummary(ctx, client, writer, "service1", DeployBucketWeek)
+	g.Expect(err).To(BeNil())
+
+	week1 := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	week2 := time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)
+	g.Expect(writer.Items).To(Equal([]CortexDeploySummaryRow{
+		{EntityTag: "service1", Bucket: "week", BucketStart: week2, Environment: "prod", DeployCount: 1},
+		{EntityTag: "service1", Bucket: "week", BucketStart: week1, Environment: "prod", DeployCount: 1, RollbackCount: 1},
+		{EntityTag: "service1", Bucket: "week", BucketStart: week1, Environment: "staging", DeployCount: 1},
+	}))
+}
+
+func TestListDeploySummaryInvalidBucket(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx, server, client := setupTestServerAndClient(t)
+	defer server.Close()
+
+	err := listDeploySummary(ctx, client, NewSliceWriter[CortexDeploySummaryRow](100), "service1", "year")
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("bucket should be day, week or month, got \"year\""))
+}
+
+func TestDeployBucketStart(t *testing.T) {
+	g := NewWithT(t)
+	// A Sunday
+	timestamp := time.Date(2025, 5, 11, 23, 30, 0, 0, time.UTC)
+
+	g.Expect(deployBucketStart(timestamp, DeployBucketDay)).To(Equal(time.Date(2025, 5, 11, 0, 0, 0, 0, time.UTC)))
+	g.Expect(deployBucketStart(timestamp, DeployBucketWeek)).To(Equal(time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)))
+	g.Expect(deployBucketStart(timestamp, DeployBucketMonth)).To(Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -2,13 +2,14 @@ package cortex
 
 import (
 	"context"
-	"fmt"
-	"strconv"
+	"net/http"
 
 	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"gopkg.in/yaml.v3"
 )
 
 type CortexDescriptorsResponse struct {
@@ -16,6 +17,28 @@ type CortexDescriptorsResponse struct {
 	Page        int      `yaml:"page"`
 	TotalPages  int      `yaml:"totalPages"`
 	Total       int      `yaml:"total"`
+	NextCursor  string   `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexDescriptorsResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
+}
+
+// The descriptors API only returns the info block, this is the version we validate against
+const DescriptorOpenapiVersion = "3.0.1"
+
+type CortexValidationResponse struct {
+	Violations []CortexValidationViolation `yaml:"violations"`
+}
+
+type CortexValidationViolation struct {
+	Path    string `yaml:"path,omitempty"`
+	Message string `yaml:"message"`
+}
+
+type CortexValidationErrorResponse struct {
+	Message string `yaml:"message"`
+	Details string `yaml:"details"`
 }
 
 func tableCortexDescriptor() *plugin.Table {
@@ -25,6 +48,9 @@ func tableCortexDescriptor() *plugin.Table {
 		List: &plugin.ListConfig{
 			Hydrate: listDescriptorsHydrator,
 		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{Func: validateDescriptorHydrator, MaxConcurrency: MaxHydrateConcurrency},
+		},
 		Columns: []*plugin.Column{
 			{Name: "tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
 			{Name: "title", Type: proto.ColumnType_STRING, Description: "Title."},
@@ -42,60 +68,105 @@ func tableCortexDescriptor() *plugin.Table {
 			{Name: "jira", Type: proto.ColumnType_JSON, Description: "List of jira projects", Transform: transform.FromField("Issues.Jira.Projects").Transform(transform.EnsureStringArray)},
 			{Name: "slos", Type: proto.ColumnType_JSON, Description: "SLOs from each integration if any", Transform: transform.FromField("SLOs")},
 			{Name: "static_analysis", Type: proto.ColumnType_JSON, Description: "Static analysis", Transform: transform.FromField("StaticAnalysis")},
+			{Name: "owner_groups", Type: proto.ColumnType_JSON, Description: "Names of the owning groups from x-cortex-owners.", Transform: transform.FromP(transform.MethodValue, "OwnerGroups").Transform(transform.EnsureStringArray)},
+			{Name: "owner_emails", Type: proto.ColumnType_JSON, Description: "Emails of the owning individuals from x-cortex-owners.", Transform: transform.FromP(transform.MethodValue, "OwnerEmails").Transform(transform.EnsureStringArray)},
+			{Name: "slo_ids", Type: proto.ColumnType_JSON, Description: "IDs of the SLOs from each integration.", Transform: transform.FromP(transform.MethodValue, "SLOIDs").Transform(transform.EnsureStringArray)},
+			{Name: "alerts", Type: proto.ColumnType_JSON, Description: "Alerts from x-cortex-alerts, each with its type and tag.", Transform: transform.FromField("Alerts")},
+			{Name: "extensions", Type: proto.ColumnType_JSON, Description: "Other x-cortex-* blocks keyed by name, e.g. x-cortex-k8s.", Transform: transform.FromP(transform.MethodValue, "Extensions")},
+			{Name: "descriptor", Type: proto.ColumnType_JSON, Description: "The whole descriptor.", Transform: transform.FromP(transform.MethodValue, "Descriptor")},
+			{Name: "descriptor_yaml", Type: proto.ColumnType_STRING, Description: "The whole descriptor as YAML with sorted keys.", Transform: transform.FromP(transform.MethodValue, "DescriptorYAML")},
+			{Name: "validation_violations", Type: proto.ColumnType_JSON, Description: "Violations from a dry-run validation of the descriptor, empty when valid.", Hydrate: validateDescriptorHydrator, Transform: transform.FromValue()},
 		},
 	}
 }
 
 func listDescriptorsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	config := GetConfig(d.Connection)
+	config := GetTableConfig(d)
 	client := CortexHTTPClient(ctx, config)
 	hydratorWriter := QueryDataWriter{d}
 	return nil, listDescriptors(ctx, client, &hydratorWriter)
 }
 
 func listDescriptors(ctx context.Context, client *req.Client, writer HydratorWriter) error {
-	logger := plugin.Logger(ctx)
-	var response CortexDescriptorsResponse
-	var page int = 0
-	for {
-		logger.Debug("listDescriptors", "page", page)
-		resp := client.
+	request := func() *req.Request {
+		return client.
 			Get("/api/v1/catalog/descriptors").
 			// Options
-			SetQueryParam("yaml", "false").
-			// Pagination
-			SetQueryParam("pageSize", "1000").
-			SetQueryParam("page", strconv.Itoa(page)).
-			Do(ctx)
-
-		// Check for HTTP errors
-		if resp.IsErrorState() {
-			logger.Error("listDescriptors", "Status", resp.Status, "Body", resp.String())
-			return fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
-		}
-
-		// Unmarshal the response and check for unmarshal errors
-		err := resp.Into(&response)
-		if err != nil {
-			logger.Error("listDescriptors", "Error", err)
-			return err
-		}
-
+			SetQueryParam("yaml", "false")
+	}
+	return cortexapi.Paginate(ctx, request, func(response CortexDescriptorsResponse) (bool, error) {
 		// Stream each row from the response, stop if we hit the limit
 		for _, result := range response.Descriptors {
 			// send the item to steampipe
 			writer.StreamListItem(ctx, result.Info)
 			// Context can be cancelled due to manual cancellation or the limit has been hit
 			if writer.RowsRemaining(ctx) == 0 {
-				return nil
+				return false, nil
 			}
 		}
+		return true, nil
+	})
+}
 
-		// Check if we have more pages
-		page++
-		if page >= response.TotalPages {
-			break
+func validateDescriptorHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	info := h.Item.(CortexInfo)
+	return validateDescriptor(ctx, client, info)
+}
+
+// Submit the descriptor to the validate endpoint, nothing is written to the catalog.
+func validateDescriptor(ctx context.Context, client *req.Client, info CortexInfo) ([]CortexValidationViolation, error) {
+	logger := plugin.Logger(ctx)
+
+	body, err := yaml.Marshal(Cortex{Openapi: DescriptorOpenapiVersion, Info: info})
+	if err != nil {
+		logger.Error("validateDescriptor", "tag", info.Tag, "Error", err)
+		return nil, err
+	}
+
+	resp := client.
+		Post("/api/v1/catalog/validate").
+		SetContentType("application/openapi;charset=UTF-8").
+		SetBody(body).
+		Do(ctx)
+
+	// There is no response when the request failed to send or was refused before sending
+	if resp.Err != nil {
+		logger.Error("validateDescriptor", "tag", info.Tag, "Error", resp.Err)
+		return nil, resp.Err
+	}
+
+	// An invalid descriptor is reported as a bad request rather than a list of violations
+	if resp.StatusCode == http.StatusBadRequest {
+		var errorResponse CortexValidationErrorResponse
+		err := resp.Into(&errorResponse)
+		if err != nil {
+			logger.Error("validateDescriptor", "tag", info.Tag, "Error", err)
+			return nil, err
 		}
+		message := errorResponse.Details
+		if message == "" {
+			message = errorResponse.Message
+		}
+		return []CortexValidationViolation{{Message: message}}, nil
+	}
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("validateDescriptor", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var response CortexValidationResponse
+	err = resp.Into(&response)
+	if err != nil {
+		logger.Error("validateDescriptor", "tag", info.Tag, "Error", err)
+		return nil, err
+	}
+	if response.Violations == nil {
+		return []CortexValidationViolation{}, nil
 	}
-	return nil
+	return response.Violations, nil
 }
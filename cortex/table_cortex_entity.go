@@ -2,13 +2,17 @@ package cortex
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"golang.org/x/sync/errgroup"
 )
 
 type ScalarOrMap struct {
@@ -38,6 +42,11 @@ type CortexEntityResponse struct {
 	Page       int                   `yaml:"page"`
 	TotalPages int                   `yaml:"totalPages"`
 	Total      int                   `yaml:"total"`
+	NextCursor string                `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexEntityResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
 }
 
 type CortexEntityElement struct {
@@ -54,10 +63,23 @@ type CortexEntityElement struct {
 	Git         CortexGithub                  `yaml:"git"`
 	Slack       []CortexSlackChannel          `yaml:"slackChannels"`
 	Owners      CortexEntityOwners            `yaml:"owners"`
+	Definition  map[string]interface{}        `yaml:"definition"`
+}
+
+// Result of the effective owners hydrate, with the error when ignore_row_errors is set
+type CortexEffectiveOwners struct {
+	Owners []string
+	Error  string
 }
 
 type CortexEntityElementHierarchy struct {
-	Parents []CortexTag `yaml:"parents"`
+	Parents []CortexEntityHierarchyNode `yaml:"parents"`
+}
+
+// Each parent in the hierarchy includes its own parents, up to the root entity
+type CortexEntityHierarchyNode struct {
+	Tag     string                      `yaml:"tag"`
+	Parents []CortexEntityHierarchyNode `yaml:"parents"`
 }
 
 type CortexEntityElementMetadata struct {
@@ -71,13 +93,90 @@ type CortexEntityOwners struct {
 }
 
 type CortexEntityOwnersTeam struct {
-	Tag string `yaml:"tag"`
+	Tag         string `yaml:"tag"`
+	Inheritance string `yaml:"inheritance,omitempty"`
 }
 
 type CortexEntityOwnersIndividual struct {
 	Email string `yaml:"email"`
 }
 
+// All unique ancestor tags, nearest parents first.
+func (e CortexEntityElement) Ancestors() []string {
+	var ancestors []string
+	visited := map[string]bool{e.Tag: true}
+	queue := e.Hierarchy.Parents
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if visited[node.Tag] {
+			continue
+		}
+		visited[node.Tag] = true
+		ancestors = append(ancestors, node.Tag)
+		queue = append(queue, node.Parents...)
+	}
+	return ancestors
+}
+
+// Tags from the root entity down to this entity, following the first parent at each level.
+func (e CortexEntityElement) HierarchyPath() []string {
+	path := []string{e.Tag}
+	visited := map[string]bool{e.Tag: true}
+	parents := e.Hierarchy.Parents
+	for len(parents) > 0 && !visited[parents[0].Tag] {
+		visited[parents[0].Tag] = true
+		path = append([]string{parents[0].Tag}, path...)
+		parents = parents[0].Parents
+	}
+	return path
+}
+
+// Wraps a HydratorWriter and only streams entities with one of the given tags
+type EntityTagFilterWriter struct {
+	Writer HydratorWriter
+	Tags   map[string]bool
+}
+
+func (w *EntityTagFilterWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		if entity, ok := item.(CortexEntityElement); ok && w.Tags[entity.Tag] {
+			w.Writer.StreamListItem(ctx, entity)
+		}
+	}
+}
+
+func (w *EntityTagFilterWriter) RowsRemaining(ctx context.Context) int64 {
+	return w.Writer.RowsRemaining(ctx)
+}
+
+// Wraps a HydratorWriter shared by parallel gets, cancelling the rest of the gets once the row limit is hit
+type ParallelWriter struct {
+	mu       sync.Mutex
+	writer   HydratorWriter
+	cancel   context.CancelFunc
+	limitHit bool
+}
+
+func (w *ParallelWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.limitHit {
+		return
+	}
+	w.writer.StreamListItem(ctx, items...)
+	if w.writer.RowsRemaining(ctx) == 0 {
+		w.limitHit = true
+		w.cancel()
+	}
+}
+
+func (w *ParallelWriter) RowsRemaining(ctx context.Context) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.RowsRemaining(ctx)
+}
+
 func tableCortexEntity() *plugin.Table {
 	return &plugin.Table{
 		Name:        "cortex_entity",
@@ -87,30 +186,91 @@ func tableCortexEntity() *plugin.Table {
 			KeyColumns: []*plugin.KeyColumn{
 				{Name: "archived", Require: plugin.Optional},
 				{Name: "type", Require: plugin.Optional},
+				{Name: "query", Require: plugin.Optional},
+				{Name: "tag", Require: plugin.Optional},
+				{Name: "search", Require: plugin.Optional},
 			},
 		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{Func: getEffectiveOwnersHydrator, MaxConcurrency: MaxHydrateConcurrency},
+		},
 		Columns: []*plugin.Column{
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity."},
 			{Name: "tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
 			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description."},
 			{Name: "type", Type: proto.ColumnType_STRING, Description: "Entity Type."},
-			{Name: "parents", Type: proto.ColumnType_JSON, Description: "Parents of the entity.", Transform: FromStructSlice[CortexTag]("Hierarchy.Parents", "Tag")},
+			{Name: "parents", Type: proto.ColumnType_JSON, Description: "Parents of the entity.", Transform: FromStructSlice[CortexEntityHierarchyNode]("Hierarchy.Parents", "Tag")},
+			{Name: "ancestors", Type: proto.ColumnType_JSON, Description: "All ancestors of the entity, nearest parents first.", Transform: transform.FromP(transform.MethodValue, "Ancestors")},
+			{Name: "hierarchy_path", Type: proto.ColumnType_JSON, Description: "Tags from the root entity down to this entity, following the first parent.", Transform: transform.FromP(transform.MethodValue, "HierarchyPath")},
 			{Name: "groups", Type: proto.ColumnType_JSON, Description: "Groups, kind of like tags."},
 			{Name: "metadata", Type: proto.ColumnType_JSON, Description: "Raw custom metadata", Transform: transform.FromField("Metadata").Transform(TagArrayToMap)},
-			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Last updated time."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Last updated time.", Transform: transform.FromField("LastUpdated").Transform(ToUTCTimestamp)},
 			{Name: "links", Type: proto.ColumnType_JSON, Description: "List of links", Transform: FromStructSlice[CortexLink]("Links", "Url")},
 			{Name: "archived", Type: proto.ColumnType_BOOL, Description: "Is archived."},
 			{Name: "repository", Type: proto.ColumnType_STRING, Description: "Git repo full name", Transform: transform.FromField("Git.Repository")},
 			{Name: "slack_channels", Type: proto.ColumnType_JSON, Description: "List of string slack channels"},
+			{Name: "slack_notifications_enabled", Type: proto.ColumnType_BOOL, Description: "True if any slack channel has notifications enabled.", Transform: transform.FromField("Slack").Transform(AnySlackNotificationsEnabled)},
 			{Name: "owner_teams", Type: proto.ColumnType_JSON, Description: "List of owning team tags", Transform: FromStructSlice[CortexEntityOwnersTeam]("Owners.Teams", "Tag")},
 			{Name: "owner_individuals", Type: proto.ColumnType_JSON, Description: "List of owning individuals emails", Transform: FromStructSlice[CortexEntityOwnersIndividual]("Owners.Individuals", "Email")},
+			{Name: "effective_owners", Type: proto.ColumnType_JSON, Description: "Owning team tags including those inherited from ancestors.", Hydrate: getEffectiveOwnersHydrator, Transform: transform.FromField("Owners")},
+			{Name: "query", Type: proto.ColumnType_STRING, Description: "CQL query the entities must match.", Transform: transform.FromQual("query")},
+			{Name: "search", Type: proto.ColumnType_STRING, Description: "Free text the entities must match, e.g. part of the name.", Transform: transform.FromQual("search")},
+			{Name: "error", Type: proto.ColumnType_STRING, Description: "Error fetching effective_owners, only set when ignore_row_errors is enabled.", Hydrate: getEffectiveOwnersHydrator, Transform: transform.FromField("Error").NullIfZero()},
 		},
 	}
 }
 
+// Optional parts of each entity in the list entities response
+type EntityIncludes struct {
+	Metadata        bool
+	Links           bool
+	SlackChannels   bool
+	Owners          bool
+	HierarchyFields bool
+}
+
+var AllEntityIncludes = EntityIncludes{Metadata: true, Links: true, SlackChannels: true, Owners: true, HierarchyFields: true}
+
+// What each cortex_entity column needs from the API, columns not listed need nothing extra
+var entityColumnIncludes = map[string]EntityIncludes{
+	"parents":                     {HierarchyFields: true},
+	"ancestors":                   {HierarchyFields: true},
+	"hierarchy_path":              {HierarchyFields: true},
+	"metadata":                    {Metadata: true},
+	"links":                       {Links: true},
+	"slack_channels":              {SlackChannels: true},
+	"slack_notifications_enabled": {SlackChannels: true},
+	"owner_teams":                 {Owners: true},
+	"owner_individuals":           {Owners: true},
+	"effective_owners":            {Owners: true, HierarchyFields: true},
+	"error":                       {Owners: true, HierarchyFields: true},
+}
+
+// Only ask the API for the parts of the entities needed by the selected columns.
+// Columns added to the table by metadata_columns need the metadata.
+func entityIncludesForColumns(table *plugin.Table, columns []string) EntityIncludes {
+	needsByColumn := make(map[string]EntityIncludes)
+	for _, column := range table.Columns {
+		needsByColumn[column.Name] = EntityIncludes{Metadata: true}
+	}
+	for _, column := range tableCortexEntity().Columns {
+		needsByColumn[column.Name] = entityColumnIncludes[column.Name]
+	}
+	var includes EntityIncludes
+	for _, column := range columns {
+		needs := needsByColumn[column]
+		includes.Metadata = includes.Metadata || needs.Metadata
+		includes.Links = includes.Links || needs.Links
+		includes.SlackChannels = includes.SlackChannels || needs.SlackChannels
+		includes.Owners = includes.Owners || needs.Owners
+		includes.HierarchyFields = includes.HierarchyFields || needs.HierarchyFields
+	}
+	return includes
+}
+
 func listEntitiesHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	config := GetConfig(d.Connection)
+	config := GetTableConfig(d)
 	client := CortexHTTPClient(ctx, config)
 	hydratorWriter := QueryDataWriter{d}
 
@@ -122,67 +282,279 @@ func listEntitiesHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.Hy
 	}
 	types := ""
 	if d.EqualsQuals["type"] != nil {
-		// When doing a "where in ()" steampipe does multiple separate calls to listEntities
-		types = d.EqualsQuals["type"].GetStringValue()
+		// When doing a "where in ()" steampipe does multiple separate calls to listEntities,
+		// the first call lists all the types at once and the others have nothing to do
+		var skip bool
+		types, skip = combineTypeQuals(d.EqualsQuals["type"], d.QueryContext.UnsafeQuals["type"])
+		if skip {
+			return nil, nil
+		}
+	}
+
+	search := ""
+	if d.EqualsQuals["search"] != nil {
+		search = d.EqualsQuals["search"].GetStringValue()
 	}
 
-	logger.Info("listEntitiesHydrator", "archived", archived, "types", types)
-	return nil, listEntities(ctx, client, &hydratorWriter, archived, types)
+	includes := entityIncludesForColumns(d.Table, d.QueryContext.Columns)
+
+	logger.Info("listEntitiesHydrator", "archived", archived, "types", types, "search", search, "includes", includes)
+
+	var writer HydratorWriter = &hydratorWriter
+	// Only stream entities matched by the CQL query
+	if d.EqualsQuals["query"] != nil {
+		query := d.EqualsQuals["query"].GetStringValue()
+		job, err := RunQuery(ctx, client, query, config.GetQueryPollInterval(), config.GetQueryTimeout())
+		if err != nil {
+			return nil, err
+		}
+		tags := make(map[string]bool, len(job.Result))
+		for _, item := range job.Result {
+			tags[item.Tag] = true
+		}
+		logger.Info("listEntitiesHydrator", "query", query, "matches", len(tags))
+		writer = &EntityTagFilterWriter{writer, tags}
+	}
+
+	// Get the entity by tag rather than listing them all, steampipe calls this for each value of tag IN (...).
+	// Getting an entity can't apply the search, so with a search the matches are listed and filtered by tag.
+	if d.EqualsQuals["tag"] != nil && search != "" {
+		tags := make(map[string]bool)
+		for _, tag := range qualStringValues(d.EqualsQuals["tag"]) {
+			tags[tag] = true
+		}
+		writer = &EntityTagFilterWriter{writer, tags}
+	} else if d.EqualsQuals["tag"] != nil {
+		connectionName := ""
+		if d.Connection != nil {
+			connectionName = d.Connection.Name
+		}
+		semaphore := getConnectionSemaphore("getEntity/"+connectionName, config.GetMaxParallelGets())
+		return nil, getEntitiesByTag(ctx, client, writer, semaphore, qualStringValues(d.EqualsQuals["tag"]), archived, types, includes)
+	}
+	return nil, listEntities(ctx, client, writer, archived, types, search, includes)
 }
 
-func listEntities(ctx context.Context, client *req.Client, writer HydratorWriter, archived string, types string) error {
+// Get the tags in parallel, each get waits for a slot of the connection's semaphore
+func getEntitiesByTag(ctx context.Context, client *req.Client, writer HydratorWriter, semaphore chan struct{}, tags []string, archived string, types string, includes EntityIncludes) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	parallelWriter := &ParallelWriter{writer: writer, cancel: cancel}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(cap(semaphore), 1))
+	for _, tag := range tags {
+		group.Go(func() error {
+			select {
+			case semaphore <- struct{}{}:
+			case <-groupCtx.Done():
+				return groupCtx.Err()
+			}
+			defer func() { <-semaphore }()
+			return getEntityByTag(groupCtx, client, parallelWriter, tag, archived, types, includes)
+		})
+	}
+	err := group.Wait()
+
+	// The gets still running when the limit was hit are cancelled, that isn't an error
+	parallelWriter.mu.Lock()
+	defer parallelWriter.mu.Unlock()
+	if parallelWriter.limitHit {
+		return nil
+	}
+	return err
+}
+
+// Combine the values of type IN (...) into the comma-separated types param.
+// Returns skip for all but the call for the first value, which lists every type.
+// Steampipe only splits one IN list into separate calls, others arrive whole and all their values are listed at once.
+func combineTypeQuals(value *proto.QualValue, quals *proto.Quals) (types string, skip bool) {
+	if value.GetListValue() != nil {
+		return strings.Join(qualStringValues(value), ","), false
+	}
+	for _, qual := range quals.GetQuals() {
+		list := qual.GetValue().GetListValue()
+		if qual.GetStringValue() != "=" || list == nil || len(list.Values) == 0 {
+			continue
+		}
+		values := make([]string, 0, len(list.Values))
+		for _, v := range list.Values {
+			values = append(values, v.GetStringValue())
+		}
+		if values[0] != value.GetStringValue() {
+			return "", true
+		}
+		return strings.Join(values, ","), false
+	}
+	return value.GetStringValue(), false
+}
+
+// The values of an equals qual, which is a list when steampipe didn't split an IN (...) into separate calls
+func qualStringValues(value *proto.QualValue) []string {
+	list := value.GetListValue()
+	if list == nil {
+		return []string{value.GetStringValue()}
+	}
+	values := make([]string, 0, len(list.Values))
+	for _, v := range list.Values {
+		values = append(values, v.GetStringValue())
+	}
+	return values
+}
+
+// Whether the entity type is one of the comma-separated types
+func containsType(types string, entityType string) bool {
+	for _, t := range strings.Split(types, ",") {
+		if t == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// Stream the entity with the tag if it exists and matches the archived and types filters
+func getEntityByTag(ctx context.Context, client *req.Client, writer HydratorWriter, tag string, archived string, types string, includes EntityIncludes) error {
+	entity, err := getEntity(ctx, client, tag, includes)
+	if err != nil || entity == nil {
+		return err
+	}
+	if entity.Archived && archived != "true" {
+		return nil
+	}
+	if types != "" && !containsType(types, entity.Type) {
+		return nil
+	}
+	writer.StreamListItem(ctx, *entity)
+	return nil
+}
+
+func listEntities(ctx context.Context, client *req.Client, writer HydratorWriter, archived string, types string, search string, includes EntityIncludes) error {
 	logger := plugin.Logger(ctx)
 
-	var response CortexEntityResponse
-	var page int = 0
-	for {
-		logger.Debug("listEntities", "page", page)
-		resp := client.
+	request := func() *req.Request {
+		r := client.
 			Get("/api/v1/catalog").
 			// Filters
 			SetQueryParam("includeArchived", archived).
-			SetQueryParam("types", types).
+			SetQueryParam("types", types)
+		if search != "" {
+			r.SetQueryParam("query", search)
+		}
+		return r.
 			// Options
 			SetQueryParam("yaml", "false").
-			SetQueryParam("includeMetadata", "true").
-			SetQueryParam("includeLinks", "true").
-			SetQueryParam("includeSlackChannels", "true").
-			SetQueryParam("includeOwners", "true").
-			SetQueryParam("includeHierarchyFields", "true").
-			// Pagination
-			SetQueryParam("pageSize", "1000").
-			SetQueryParam("page", strconv.Itoa(page)).
-			Do(ctx)
-
-		// Check for HTTP errors
-		if resp.IsErrorState() {
-			logger.Error("listEntities", "Status", resp.Status, "Body", resp.String())
-			return fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
-		}
-
-		// Unmarshal the response and check for unmarshal errors
-		err := resp.Into(&response)
-		if err != nil {
-			logger.Error("listEntities", "page", page, "Error", err)
-			return err
+			SetQueryParam("includeMetadata", strconv.FormatBool(includes.Metadata)).
+			SetQueryParam("includeLinks", strconv.FormatBool(includes.Links)).
+			SetQueryParam("includeSlackChannels", strconv.FormatBool(includes.SlackChannels)).
+			SetQueryParam("includeOwners", strconv.FormatBool(includes.Owners)).
+			SetQueryParam("includeHierarchyFields", strconv.FormatBool(includes.HierarchyFields))
+	}
+	// Catalog pages can be large, entities are streamed from the body as they are decoded
+	return cortexapi.PaginateItems(ctx, request, "entities", func(result CortexEntityElement) (bool, error) {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, result)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			logger.Debug("listEntities", "RowsRemaining", writer.RowsRemaining(ctx))
+			return false, nil
 		}
+		return true, nil
+	})
+}
+
+func getEffectiveOwnersHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	entity := h.Item.(CortexEntityElement)
+	owners, err := getEffectiveOwners(ctx, client, entity)
+	if err != nil {
+		message, err := rowError(ctx, d, err)
+		return CortexEffectiveOwners{Error: message}, err
+	}
+	return CortexEffectiveOwners{Owners: owners}, nil
+}
 
-		logger.Debug("listEntities", "totalPages", response.TotalPages, "total", response.Total)
+// Direct owning teams plus those inherited from ancestors, nearest ancestors first.
+// APPEND owners always pass down, FALLBACK owners only when the entity has no owning team of its own.
+func getEffectiveOwners(ctx context.Context, client *req.Client, entity CortexEntityElement) ([]string, error) {
+	var owners []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			owners = append(owners, tag)
+		}
+	}
+	for _, team := range entity.Owners.Teams {
+		add(team.Tag)
+	}
+	hasDirectOwners := len(owners) > 0
 
-		for _, result := range response.Entities {
-			// send the item to steampipe
-			writer.StreamListItem(ctx, result)
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if writer.RowsRemaining(ctx) == 0 {
-				logger.Debug("listEntities", "RowsRemaining", writer.RowsRemaining(ctx))
-				return nil
-			}
+	// Fetch the ancestors in parallel, the results keep the nearest first order
+	tags := entity.Ancestors()
+	ancestors := make([]*CortexEntityElement, len(tags))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(MaxHydrateConcurrency)
+	for i, tag := range tags {
+		group.Go(func() error {
+			ancestor, err := getEntity(groupCtx, client, tag, EntityIncludes{Owners: true})
+			ancestors[i] = ancestor
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor == nil {
+			continue
 		}
-		page++
-		if page >= response.TotalPages {
-			logger.Debug("listEntities", "page", page, "totalPages", response.TotalPages)
-			break
+		for _, team := range ancestor.Owners.Teams {
+			switch strings.ToUpper(team.Inheritance) {
+			case "APPEND":
+				add(team.Tag)
+			case "FALLBACK":
+				if !hasDirectOwners {
+					add(team.Tag)
+				}
+			}
 		}
 	}
-	return nil
+	return owners, nil
+}
+
+// Get a single entity, nil if there is no entity with the tag
+func getEntity(ctx context.Context, client *req.Client, tag string, includes EntityIncludes) (*CortexEntityElement, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/catalog/{tag}").
+		SetPathParam("tag", tag).
+		// Options
+		SetQueryParam("yaml", "false").
+		SetQueryParam("includeMetadata", strconv.FormatBool(includes.Metadata)).
+		SetQueryParam("includeLinks", strconv.FormatBool(includes.Links)).
+		SetQueryParam("includeSlackChannels", strconv.FormatBool(includes.SlackChannels)).
+		SetQueryParam("includeOwners", strconv.FormatBool(includes.Owners)).
+		SetQueryParam("includeHierarchyFields", strconv.FormatBool(includes.HierarchyFields)).
+		Do(ctx)
+
+	if resp.GetStatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getEntity", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var entity CortexEntityElement
+	err := resp.Into(&entity)
+	if err != nil {
+		logger.Error("getEntity", "Error", err)
+		return nil, err
+	}
+	return &entity, nil
 }
@@ -0,0 +1,212 @@
+package cortex
+
+import (
+	"context"
+	"sort"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Kinds of event in the timeline
+const (
+	EntityEventDeploy      = "deploy"
+	EntityEventCustomEvent = "custom_event"
+)
+
+// Response elements for the /catalog/{tag}/deploys endpoint
+type CortexDeployResponse struct {
+	Deployments []CortexDeploy `yaml:"deployments"`
+	Page        int            `yaml:"page"`
+	TotalPages  int            `yaml:"totalPages"`
+	Total       int            `yaml:"total"`
+	NextCursor  string         `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexDeployResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
+}
+
+type CortexDeploy struct {
+	UUID        string                 `yaml:"uuid"`
+	Title       string                 `yaml:"title"`
+	Timestamp   string                 `yaml:"timestamp"`
+	Type        string                 `yaml:"type"`
+	Sha         string                 `yaml:"sha"`
+	Environment string                 `yaml:"environment"`
+	Deployer    CortexDeployer         `yaml:"deployer"`
+	CustomData  map[string]interface{} `yaml:"customData"`
+}
+
+type CortexDeployer struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+}
+
+// Response elements for the /catalog/{tag}/custom-events endpoint
+type CortexCustomEventResponse struct {
+	Events []CortexCustomEvent `yaml:"events"`
+}
+
+type CortexCustomEvent struct {
+	UUID        string                 `yaml:"uuid"`
+	Title       string                 `yaml:"title"`
+	Description string                 `yaml:"description"`
+	Timestamp   string                 `yaml:"timestamp"`
+	Type        string                 `yaml:"type"`
+	CustomData  map[string]interface{} `yaml:"customData"`
+}
+
+// Used to represent the data we want to return in the table
+type CortexEntityEventRow struct {
+	EntityTag     string
+	Type          string
+	EventType     string
+	UUID          string
+	Title         string
+	Description   string
+	Timestamp     string
+	Environment   string
+	Sha           string
+	DeployerEmail string
+	CustomData    map[string]interface{}
+}
+
+func tableCortexEntityEvent() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_event",
+		Description: "Cortex timeline of deploys and custom events for an entity.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityEventsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "entity_tag", Require: plugin.Required},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "type", Type: proto.ColumnType_STRING, Description: "Kind of event, deploy or custom_event."},
+			{Name: "event_type", Type: proto.ColumnType_STRING, Description: "Type reported for the event, e.g. DEPLOY or ROLLBACK for deploys."},
+			{Name: "uuid", Type: proto.ColumnType_STRING, Description: "Id of the event.", Transform: transform.FromField("UUID")},
+			{Name: "title", Type: proto.ColumnType_STRING, Description: "Title."},
+			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description."},
+			{Name: "timestamp", Type: proto.ColumnType_TIMESTAMP, Description: "Time of the event.", Transform: transform.FromField("Timestamp").Transform(ToUTCTimestamp)},
+			{Name: "environment", Type: proto.ColumnType_STRING, Description: "Environment of a deploy."},
+			{Name: "sha", Type: proto.ColumnType_STRING, Description: "Git sha of a deploy."},
+			{Name: "deployer_email", Type: proto.ColumnType_STRING, Description: "Email of who made a deploy."},
+			{Name: "custom_data", Type: proto.ColumnType_JSON, Description: "Custom data attached to the event."},
+		},
+	}
+}
+
+func listEntityEventsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	entityTag := d.EqualsQuals["entity_tag"].GetStringValue()
+	logger.Info("listEntityEventsHydrator", "entityTag", entityTag)
+	return nil, listEntityEvents(ctx, client, &writer, entityTag)
+}
+
+func listEntityEvents(ctx context.Context, client *req.Client, writer HydratorWriter, entityTag string) error {
+	logger := plugin.Logger(ctx)
+
+	deploys, err := getDeploys(ctx, client, entityTag)
+	if err != nil {
+		return err
+	}
+	events, err := getCustomEvents(ctx, client, entityTag)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]CortexEntityEventRow, 0, len(deploys)+len(events))
+	for _, deploy := range deploys {
+		rows = append(rows, CortexEntityEventRow{
+			EntityTag:     entityTag,
+			Type:          EntityEventDeploy,
+			EventType:     deploy.Type,
+			UUID:          deploy.UUID,
+			Title:         deploy.Title,
+			Timestamp:     deploy.Timestamp,
+			Environment:   deploy.Environment,
+			Sha:           deploy.Sha,
+			DeployerEmail: deploy.Deployer.Email,
+			CustomData:    deploy.CustomData,
+		})
+	}
+	for _, event := range events {
+		rows = append(rows, CortexEntityEventRow{
+			EntityTag:   entityTag,
+			Type:        EntityEventCustomEvent,
+			EventType:   event.Type,
+			UUID:        event.UUID,
+			Title:       event.Title,
+			Description: event.Description,
+			Timestamp:   event.Timestamp,
+			CustomData:  event.CustomData,
+		})
+	}
+
+	// Newest events first, unparseable timestamps sort last
+	sort.SliceStable(rows, func(i, j int) bool {
+		left, _ := ParseCortexTime(rows[i].Timestamp)
+		right, _ := ParseCortexTime(rows[j].Timestamp)
+		return left.After(right)
+	})
+	logger.Info("listEntityEvents", "deploys", len(deploys), "events", len(events))
+
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+func getDeploys(ctx context.Context, client *req.Client, entityTag string) ([]CortexDeploy, error) {
+	var deploys []CortexDeploy
+	request := func() *req.Request {
+		return client.
+			Get("/api/v1/catalog/{tag}/deploys").
+			SetPathParam("tag", entityTag)
+	}
+	err := cortexapi.Paginate(ctx, request, func(response CortexDeployResponse) (bool, error) {
+		deploys = append(deploys, response.Deployments...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deploys, nil
+}
+
+func getCustomEvents(ctx context.Context, client *req.Client, entityTag string) ([]CortexCustomEvent, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/catalog/{tag}/custom-events").
+		SetPathParam("tag", entityTag).
+		Do(ctx)
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getCustomEvents", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var response CortexCustomEventResponse
+	err := resp.Into(&response)
+	if err != nil {
+		logger.Error("getCustomEvents", "Error", err)
+		return nil, err
+	}
+	return response.Events, nil
+}
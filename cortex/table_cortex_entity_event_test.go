@@ -0,0 +1,148 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+func prepareDeployResponse(t *testing.T, deploys []CortexDeploy, page, totalPages, total int) []byte {
+	t.Helper()
+	response := CortexDeployResponse{
+		Deployments: deploys,
+		Page:        page,
+		TotalPages:  totalPages,
+		Total:       total,
+	}
+	responseBytes, err := yaml.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func prepareCustomEventResponse(t *testing.T, events []CortexCustomEvent) []byte {
+	t.Helper()
+	response := CortexCustomEventResponse{
+		Events: events,
+	}
+	responseBytes, err := yaml.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func TestTableCortexEntityEvent(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityEvent()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_event"))
+	g.Expect(table.Description).To(Equal("Cortex timeline of deploys and custom events for an entity."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("entity_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"entity_tag", proto.ColumnType_STRING},
+		{"type", proto.ColumnType_STRING},
+		{"event_type", proto.ColumnType_STRING},
+		{"uuid", proto.ColumnType_STRING},
+		{"title", proto.ColumnType_STRING},
+		{"description", proto.ColumnType_STRING},
+		{"timestamp", proto.ColumnType_TIMESTAMP},
+		{"environment", proto.ColumnType_STRING},
+		{"sha", proto.ColumnType_STRING},
+		{"deployer_email", proto.ColumnType_STRING},
+		{"custom_data", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityEvents(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	deploysPage0 := prepareDeployResponse(t, []CortexDeploy{
+		{UUID: "deploy1", Type: "DEPLOY", Timestamp: "2025-05-01T12:00:00Z", Deployer: CortexDeployer{Email: "dev@example.com"}},
+	}, 0, 2, 2)
+	deploysPage1 := prepareDeployResponse(t, []CortexDeploy{
+		{UUID: "deploy2", Type: "ROLLBACK", Timestamp: "2025-05-03T12:00:00Z"},
+	}, 1, 2, 2)
+	events := prepareCustomEventResponse(t, []CortexCustomEvent{
+		{UUID: "event1", Type: "incident", Timestamp: "2025-05-02T12:00:00Z"},
+	})
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1/deploys"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, deploysPage0, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1/deploys"),
+			gh.RespondWith(http.StatusOK, deploysPage1, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1/custom-events"),
+			gh.RespondWith(http.StatusOK, events, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityEventRow](100)
+
+	err := listEntityEvents(ctx, client, writer, "service1")
+	g.Expect(err).To(BeNil())
+
+	// Events are merged newest first
+	g.Expect(writer.Items).To(HaveLen(3))
+	g.Expect(writer.Items[0].UUID).To(Equal("deploy2"))
+	g.Expect(writer.Items[0].Type).To(Equal(EntityEventDeploy))
+	g.Expect(writer.Items[0].EventType).To(Equal("ROLLBACK"))
+	g.Expect(writer.Items[1].UUID).To(Equal("event1"))
+	g.Expect(writer.Items[1].Type).To(Equal(EntityEventCustomEvent))
+	g.Expect(writer.Items[2].UUID).To(Equal("deploy1"))
+	g.Expect(writer.Items[2].DeployerEmail).To(Equal("dev@example.com"))
+	g.Expect(writer.Items[2].EntityTag).To(Equal("service1"))
+}
+
+func TestListEntityEventsError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1/deploys"),
+			gh.RespondWith(http.StatusInternalServerError, "{\"details\": \"fake error on deploys\"}", nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityEventRow](100)
+
+	err := listEntityEvents(ctx, client, writer, "service1")
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 500 Internal Server Error: {\"details\": \"fake error on deploys\"}"))
+}
@@ -0,0 +1,102 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexEntityIncidentIntegrationRow struct {
+	EntityTag      string
+	Provider       string
+	ServiceID      string
+	IdentifierType string
+	Alias          string
+}
+
+func tableCortexEntityIncidentIntegration() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_incident_integration",
+		Description: "Incident management registrations of each entity from its descriptor, for incident.io, Opsgenie and FireHydrant.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityIncidentIntegrationsHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "provider", Type: proto.ColumnType_STRING, Description: "Incident management provider, one of incident_io, opsgenie or firehydrant."},
+			{Name: "service_id", Type: proto.ColumnType_STRING, Description: "Identifier of the entity in the provider, e.g. the FireHydrant service or Opsgenie schedule."},
+			{Name: "identifier_type", Type: proto.ColumnType_STRING, Description: "What the service_id identifies, e.g. ID or SLUG for FireHydrant, SCHEDULE for Opsgenie and the custom field name or id for incident.io.", Transform: transform.FromField("IdentifierType").NullIfZero()},
+			{Name: "alias", Type: proto.ColumnType_STRING, Description: "Alias of the provider configuration in Cortex used to route to the provider account, null for the default.", Transform: transform.FromField("Alias").NullIfZero()},
+		},
+	}
+}
+
+func listEntityIncidentIntegrationsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listEntityIncidentIntegrations(ctx, client, &hydratorWriter)
+}
+
+func listEntityIncidentIntegrations(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+	request := func() *req.Request {
+		return client.
+			Get("/api/v1/catalog/descriptors").
+			// Options
+			SetQueryParam("yaml", "false")
+	}
+	return cortexapi.Paginate(ctx, request, func(response CortexDescriptorsResponse) (bool, error) {
+		for _, result := range response.Descriptors {
+			rows, err := incidentIntegrations(result.Info)
+			if err != nil {
+				logger.Error("listEntityIncidentIntegrations", "tag", result.Info.Tag, "Error", err)
+				return false, err
+			}
+			for _, row := range rows {
+				// send the item to steampipe
+				writer.StreamListItem(ctx, row)
+				// Context can be cancelled due to manual cancellation or the limit has been hit
+				if writer.RowsRemaining(ctx) == 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+}
+
+// A row for each incident management registration in the descriptor
+func incidentIntegrations(info CortexInfo) ([]CortexEntityIncidentIntegrationRow, error) {
+	var rows []CortexEntityIncidentIntegrationRow
+
+	var incidentIO CortexIncidentIO
+	if err := info.DecodeExtension("x-cortex-incident-io", &incidentIO); err != nil {
+		return nil, err
+	}
+	for _, field := range incidentIO.CustomFields {
+		identifierType := field.Name
+		if identifierType == "" {
+			identifierType = field.ID
+		}
+		rows = append(rows, CortexEntityIncidentIntegrationRow{EntityTag: info.Tag, Provider: "incident_io", ServiceID: field.Value, IdentifierType: identifierType, Alias: field.Alias})
+	}
+
+	if opsgenie := info.Oncall.Opsgenie; opsgenie.ID != "" {
+		rows = append(rows, CortexEntityIncidentIntegrationRow{EntityTag: info.Tag, Provider: "opsgenie", ServiceID: opsgenie.ID, IdentifierType: opsgenie.Type, Alias: opsgenie.Alias})
+	}
+
+	var fireHydrant CortexFireHydrant
+	if err := info.DecodeExtension("x-cortex-firehydrant", &fireHydrant); err != nil {
+		return nil, err
+	}
+	for _, service := range fireHydrant.Services {
+		rows = append(rows, CortexEntityIncidentIntegrationRow{EntityTag: info.Tag, Provider: "firehydrant", ServiceID: service.Identifier, IdentifierType: service.IdentifierType, Alias: service.Alias})
+	}
+	return rows, nil
+}
@@ -0,0 +1,109 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func TestTableCortexEntityIncidentIntegration(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityIncidentIntegration()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_incident_integration"))
+	g.Expect(table.Description).To(Equal("Incident management registrations of each entity from its descriptor, for incident.io, Opsgenie and FireHydrant."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"entity_tag", proto.ColumnType_STRING},
+		{"provider", proto.ColumnType_STRING},
+		{"service_id", proto.ColumnType_STRING},
+		{"identifier_type", proto.ColumnType_STRING},
+		{"alias", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityIncidentIntegrations(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	descriptors := `
+descriptors:
+  - info:
+      x-cortex-tag: service1
+      x-cortex-oncall:
+        opsgenie:
+          type: SCHEDULE
+          id: service1-schedule
+      x-cortex-firehydrant:
+        services:
+          - identifier: ASDF1234
+            identifierType: ID
+          - identifier: service-one
+            identifierType: SLUG
+            alias: eu
+  - info:
+      x-cortex-tag: service2
+      x-cortex-incident-io:
+        customFields:
+          - name: Entity
+            value: Service 2
+          - id: 01GW2G3V0S59R238FAHPDS1R66
+            value: service2
+            alias: other-workspace
+  - info:
+      x-cortex-tag: service3
+page: 0
+totalPages: 1
+`
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/descriptors"),
+			gh.RespondWith(http.StatusOK, descriptors),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityIncidentIntegrationRow](100)
+	err := listEntityIncidentIntegrations(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(Equal([]CortexEntityIncidentIntegrationRow{
+		{EntityTag: "service1", Provider: "opsgenie", ServiceID: "service1-schedule", IdentifierType: "SCHEDULE"},
+		{EntityTag: "service1", Provider: "firehydrant", ServiceID: "ASDF1234", IdentifierType: "ID"},
+		{EntityTag: "service1", Provider: "firehydrant", ServiceID: "service-one", IdentifierType: "SLUG", Alias: "eu"},
+		{EntityTag: "service2", Provider: "incident_io", ServiceID: "Service 2", IdentifierType: "Entity"},
+		{EntityTag: "service2", Provider: "incident_io", ServiceID: "service2", IdentifierType: "01GW2G3V0S59R238FAHPDS1R66", Alias: "other-workspace"},
+	}))
+}
+
+func TestListEntityIncidentIntegrationsInvalidBlock(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	descriptors := "descriptors:\n  - info:\n      x-cortex-tag: service1\n      x-cortex-firehydrant:\n        services: not-a-list\npage: 0\ntotalPages: 1\n"
+	ctx, server, client := setupTestServerAndClient(t, gh.RespondWith(http.StatusOK, descriptors))
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityIncidentIntegrationRow](100)
+	err := listEntityIncidentIntegrations(ctx, client, writer)
+	g.Expect(err).To(HaveOccurred())
+}
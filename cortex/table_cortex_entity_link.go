@@ -0,0 +1,79 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexEntityLinkRow struct {
+	EntityTag  string
+	EntityName string
+	EntityType string
+	Link       CortexLink
+}
+
+// Wraps a HydratorWriter and streams a row for each link of an entity
+type EntityLinkWriter struct {
+	Writer HydratorWriter
+}
+
+func (w *EntityLinkWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		entity, ok := item.(CortexEntityElement)
+		if !ok {
+			continue
+		}
+		for _, link := range entity.Links {
+			w.Writer.StreamListItem(ctx, CortexEntityLinkRow{
+				EntityTag:  entity.Tag,
+				EntityName: entity.Name,
+				EntityType: entity.Type,
+				Link:       link,
+			})
+		}
+	}
+}
+
+func (w *EntityLinkWriter) RowsRemaining(ctx context.Context) int64 {
+	return w.Writer.RowsRemaining(ctx)
+}
+
+func tableCortexEntityLink() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_link",
+		Description: "Cortex links of each entity.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityLinksHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "entity_type", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "entity_name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity."},
+			{Name: "entity_type", Type: proto.ColumnType_STRING, Description: "Entity Type."},
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the link.", Transform: transform.FromField("Link.Name")},
+			{Name: "type", Type: proto.ColumnType_STRING, Description: "Type of the link, e.g. runbook or dashboard.", Transform: transform.FromField("Link.Type")},
+			{Name: "url", Type: proto.ColumnType_STRING, Description: "Link URL.", Transform: transform.FromField("Link.Url")},
+		},
+	}
+}
+
+func listEntityLinksHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := EntityLinkWriter{&QueryDataWriter{d}}
+
+	types := ""
+	if d.EqualsQuals["entity_type"] != nil {
+		types = d.EqualsQuals["entity_type"].GetStringValue()
+	}
+
+	logger.Info("listEntityLinksHydrator", "types", types)
+	return nil, listEntities(ctx, client, &hydratorWriter, "false", types, "", EntityIncludes{Links: true})
+}
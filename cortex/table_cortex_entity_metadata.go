@@ -0,0 +1,95 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexEntityMetadataRow struct {
+	EntityTag  string
+	EntityName string
+	EntityType string
+	Key        string
+	Value      interface{}
+}
+
+// Wraps a HydratorWriter and streams a row for each custom metadata entry of an entity
+type EntityMetadataWriter struct {
+	Writer HydratorWriter
+}
+
+func (w *EntityMetadataWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		entity, ok := item.(CortexEntityElement)
+		if !ok {
+			continue
+		}
+		for _, metadata := range entity.Metadata {
+			w.Writer.StreamListItem(ctx, CortexEntityMetadataRow{
+				EntityTag:  entity.Tag,
+				EntityName: entity.Name,
+				EntityType: entity.Type,
+				Key:        metadata.Key,
+				Value:      metadata.Value.Value(),
+			})
+		}
+	}
+}
+
+func (w *EntityMetadataWriter) RowsRemaining(ctx context.Context) int64 {
+	return w.Writer.RowsRemaining(ctx)
+}
+
+func tableCortexEntityMetadata() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_metadata",
+		Description: "Cortex custom metadata entries of each entity.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityMetadataHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "entity_type", Require: plugin.Optional},
+				{Name: "entity_tag", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "entity_name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity."},
+			{Name: "entity_type", Type: proto.ColumnType_STRING, Description: "Entity Type."},
+			{Name: "key", Type: proto.ColumnType_STRING, Description: "Metadata key."},
+			{Name: "value", Type: proto.ColumnType_JSON, Description: "Metadata value.", Transform: transform.FromField("Value")},
+		},
+	}
+}
+
+func listEntityMetadataHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := EntityMetadataWriter{&QueryDataWriter{d}}
+
+	types := ""
+	if d.EqualsQuals["entity_type"] != nil {
+		types = d.EqualsQuals["entity_type"].GetStringValue()
+	}
+
+	// Get the entity by tag rather than listing them all, steampipe calls this for each value of entity_tag IN (...)
+	if d.EqualsQuals["entity_tag"] != nil {
+		tag := d.EqualsQuals["entity_tag"].GetStringValue()
+		logger.Info("listEntityMetadataHydrator", "types", types, "tag", tag)
+		connectionName := ""
+		if d.Connection != nil {
+			connectionName = d.Connection.Name
+		}
+		semaphore := getConnectionSemaphore("getEntity/"+connectionName, config.GetMaxParallelGets())
+		semaphore <- struct{}{}
+		defer func() { <-semaphore }()
+		return nil, getEntityByTag(ctx, client, &hydratorWriter, tag, "false", types, EntityIncludes{Metadata: true})
+	}
+
+	logger.Info("listEntityMetadataHydrator", "types", types)
+	return nil, listEntities(ctx, client, &hydratorWriter, "false", types, "", EntityIncludes{Metadata: true})
+}
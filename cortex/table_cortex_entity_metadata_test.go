@@ -0,0 +1,105 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexEntityMetadata(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityMetadata()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_metadata"))
+	g.Expect(table.Description).To(Equal("Cortex custom metadata entries of each entity."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(2))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("entity_type"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("entity_tag"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"entity_tag", proto.ColumnType_STRING},
+		{"entity_name", proto.ColumnType_STRING},
+		{"entity_type", proto.ColumnType_STRING},
+		{"key", proto.ColumnType_STRING},
+		{"value", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityMetadata(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := []byte(`{"entities": [{"tag": "service1", "type": "service", "metadata": [
+		{"key": "tier", "value": 1},
+		{"key": "cost", "value": {"center": "cc-1"}}
+	]}, {"tag": "service2", "type": "service"}], "page": 0, "totalPages": 1, "total": 2}`)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityMetadataRow](100)
+
+	err := listEntities(ctx, client, &EntityMetadataWriter{writer}, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].EntityTag).To(Equal("service1"))
+	g.Expect(writer.Items[0].Key).To(Equal("tier"))
+	g.Expect(writer.Items[0].Value).To(Equal(1))
+	g.Expect(writer.Items[1].Value).To(Equal(map[string]interface{}{"center": "cc-1"}))
+}
+
+func TestGetEntityMetadataByTag(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := []byte(`{"tag": "service1", "type": "service", "metadata": [{"key": "tier", "value": 1}]}`)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1"),
+			gh.VerifyFormKV("includeMetadata", "true"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityMetadataRow](100)
+
+	err := getEntityByTag(ctx, client, &EntityMetadataWriter{writer}, "service1", "false", "", EntityIncludes{Metadata: true})
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].EntityTag).To(Equal("service1"))
+	g.Expect(writer.Items[0].Key).To(Equal("tier"))
+	g.Expect(writer.Items[0].Value).To(Equal(1))
+}
@@ -0,0 +1,102 @@
+package cortex
+
+import (
+	"context"
+	"sort"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// Used to represent the data we want to return in the table
+type CortexEntityRelationshipRow struct {
+	ParentTag  string
+	ParentType string
+	ChildTag   string
+	ChildType  string
+}
+
+// Collects the type and direct parents of each entity streamed by listEntities.
+type EntityHierarchyWriter struct {
+	Types   map[string]string
+	Parents map[string][]string
+}
+
+func (w *EntityHierarchyWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		if entity, ok := item.(CortexEntityElement); ok {
+			w.Types[entity.Tag] = entity.Type
+			for _, parent := range entity.Hierarchy.Parents {
+				w.Parents[entity.Tag] = append(w.Parents[entity.Tag], parent.Tag)
+			}
+		}
+	}
+}
+
+// The parents of every entity are needed, so never stop early
+func (w *EntityHierarchyWriter) RowsRemaining(ctx context.Context) int64 {
+	return 1
+}
+
+func tableCortexEntityRelationship() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_relationship",
+		Description: "Direct parent and child relationships between Cortex entities, e.g. domains and their services.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityRelationshipsHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "parent_tag", Type: proto.ColumnType_STRING, Description: "Tag of the parent entity."},
+			{Name: "parent_type", Type: proto.ColumnType_STRING, Description: "Type of the parent entity, e.g. domain. Null when the parent is archived."},
+			{Name: "child_tag", Type: proto.ColumnType_STRING, Description: "Tag of the child entity."},
+			{Name: "child_type", Type: proto.ColumnType_STRING, Description: "Type of the child entity, e.g. service."},
+		},
+	}
+}
+
+func listEntityRelationshipsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listEntityRelationships(ctx, client, &hydratorWriter)
+}
+
+// Stream an edge for each direct parent of every entity, ordered by parent then child
+func listEntityRelationships(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+
+	hierarchy := EntityHierarchyWriter{Types: make(map[string]string), Parents: make(map[string][]string)}
+	err := listEntities(ctx, client, &hierarchy, "false", "", "", EntityIncludes{HierarchyFields: true})
+	if err != nil {
+		return err
+	}
+
+	var rows []CortexEntityRelationshipRow
+	for child, parents := range hierarchy.Parents {
+		for _, parent := range parents {
+			rows = append(rows, CortexEntityRelationshipRow{
+				ParentTag:  parent,
+				ParentType: hierarchy.Types[parent],
+				ChildTag:   child,
+				ChildType:  hierarchy.Types[child],
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ParentTag != rows[j].ParentTag {
+			return rows[i].ParentTag < rows[j].ParentTag
+		}
+		return rows[i].ChildTag < rows[j].ChildTag
+	})
+	logger.Info("listEntityRelationships", "results", len(rows))
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
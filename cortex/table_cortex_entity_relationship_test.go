@@ -0,0 +1,76 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func TestTableCortexEntityRelationship(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityRelationship()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_relationship"))
+	g.Expect(table.Description).To(Equal("Direct parent and child relationships between Cortex entities, e.g. domains and their services."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"parent_tag", proto.ColumnType_STRING},
+		{"parent_type", proto.ColumnType_STRING},
+		{"child_tag", proto.ColumnType_STRING},
+		{"child_type", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityRelationships(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	entities := []CortexEntityElement{
+		{Tag: "payments", Type: "domain"},
+		{Tag: "billing", Type: "domain", Hierarchy: CortexEntityElementHierarchy{Parents: []CortexEntityHierarchyNode{{Tag: "payments"}}}},
+		{Tag: "invoices", Type: "service", Hierarchy: CortexEntityElementHierarchy{Parents: []CortexEntityHierarchyNode{
+			// Only the direct parents are edges
+			{Tag: "billing", Parents: []CortexEntityHierarchyNode{{Tag: "payments"}}},
+			{Tag: "archived-domain"},
+		}}},
+		{Tag: "cards", Type: "service", Hierarchy: CortexEntityElementHierarchy{Parents: []CortexEntityHierarchyNode{{Tag: "payments"}}}},
+	}
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("includeHierarchyFields", "true"),
+			gh.RespondWith(http.StatusOK, prepareEntityResponse(t, entities, 0, 1, len(entities))),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityRelationshipRow](100)
+	err := listEntityRelationships(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(Equal([]CortexEntityRelationshipRow{
+		{ParentTag: "archived-domain", ParentType: "", ChildTag: "invoices", ChildType: "service"},
+		{ParentTag: "billing", ParentType: "domain", ChildTag: "invoices", ChildType: "service"},
+		{ParentTag: "payments", ParentType: "domain", ChildTag: "billing", ChildType: "domain"},
+		{ParentTag: "payments", ParentType: "domain", ChildTag: "cards", ChildType: "service"},
+	}))
+}
@@ -0,0 +1,95 @@
+package cortex
+
+import (
+	"context"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Link types that Cortex renders as documentation on the entity page
+var techDocLinkTypes = []string{"documentation", "openapi", "async_api"}
+
+// Used to represent the data we want to return in the table
+type CortexEntityTechDocRow struct {
+	EntityTag  string
+	EntityName string
+	EntityType string
+	Link       CortexLink
+}
+
+// Wraps a HydratorWriter and streams a row for each documentation link of an entity
+type EntityTechDocWriter struct {
+	Writer HydratorWriter
+}
+
+func (w *EntityTechDocWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		entity, ok := item.(CortexEntityElement)
+		if !ok {
+			continue
+		}
+		for _, link := range entity.Links {
+			if !isTechDocLink(link) {
+				continue
+			}
+			w.Writer.StreamListItem(ctx, CortexEntityTechDocRow{
+				EntityTag:  entity.Tag,
+				EntityName: entity.Name,
+				EntityType: entity.Type,
+				Link:       link,
+			})
+		}
+	}
+}
+
+func (w *EntityTechDocWriter) RowsRemaining(ctx context.Context) int64 {
+	return w.Writer.RowsRemaining(ctx)
+}
+
+func isTechDocLink(link CortexLink) bool {
+	for _, linkType := range techDocLinkTypes {
+		if strings.EqualFold(link.Type, linkType) {
+			return true
+		}
+	}
+	return false
+}
+
+func tableCortexEntityTechDoc() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_tech_doc",
+		Description: "Cortex documentation links of each entity.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityTechDocsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "entity_type", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity."},
+			{Name: "entity_name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity."},
+			{Name: "entity_type", Type: proto.ColumnType_STRING, Description: "Entity Type."},
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the documentation link.", Transform: transform.FromField("Link.Name")},
+			{Name: "source", Type: proto.ColumnType_STRING, Description: "Kind of documentation, e.g. documentation or openapi.", Transform: transform.FromField("Link.Type")},
+			{Name: "url", Type: proto.ColumnType_STRING, Description: "Location of the documentation.", Transform: transform.FromField("Link.Url")},
+		},
+	}
+}
+
+func listEntityTechDocsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := EntityTechDocWriter{&QueryDataWriter{d}}
+
+	types := ""
+	if d.EqualsQuals["entity_type"] != nil {
+		types = d.EqualsQuals["entity_type"].GetStringValue()
+	}
+
+	logger.Info("listEntityTechDocsHydrator", "types", types)
+	return nil, listEntities(ctx, client, &hydratorWriter, "false", types, "", EntityIncludes{Links: true})
+}
@@ -0,0 +1,81 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexEntityTechDoc(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityTechDoc()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_tech_doc"))
+	g.Expect(table.Description).To(Equal("Cortex documentation links of each entity."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("entity_type"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Optional))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"entity_tag", proto.ColumnType_STRING},
+		{"entity_name", proto.ColumnType_STRING},
+		{"entity_type", proto.ColumnType_STRING},
+		{"name", proto.ColumnType_STRING},
+		{"source", proto.ColumnType_STRING},
+		{"url", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityTechDocs(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareEntityResponse(t, []CortexEntityElement{
+		{Tag: "service1", Type: "service", Links: []CortexLink{
+			{Name: "Docs", Type: "DOCUMENTATION", Url: "https://docs.example.com/service1"},
+			{Name: "Runbook", Type: "runbook", Url: "https://runbooks.example.com/service1"},
+			{Name: "API", Type: "openapi", Url: "https://example.com/service1/openapi.yaml"},
+		}},
+		{Tag: "service2", Type: "service"},
+	}, 0, 1, 2)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityTechDocRow](100)
+
+	err := listEntities(ctx, client, &EntityTechDocWriter{writer}, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].EntityTag).To(Equal("service1"))
+	g.Expect(writer.Items[0].Link.Name).To(Equal("Docs"))
+	g.Expect(writer.Items[1].Link.Name).To(Equal("API"))
+}
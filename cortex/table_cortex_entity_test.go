@@ -1,13 +1,17 @@
 package cortex
 
 import (
+	"context"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 	"gopkg.in/yaml.v3"
 )
 
@@ -43,13 +47,60 @@ func TestListEntitiesSinglePage(t *testing.T) {
 
 	writer := NewSliceWriter[CortexEntityElement](100)
 
-	err := listEntities(ctx, client, writer, "false", "")
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
 	g.Expect(err).To(BeNil())
 
 	g.Expect(writer.Items).To(HaveLen(1))
 	g.Expect(writer.Items[0].Name).To(Equal("entity1"))
 }
 
+func TestListEntitiesArchived(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareEntityResponse(t, []CortexEntityElement{{Name: "entity1", Archived: true}}, 0, 1, 1)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("includeArchived", "true"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err := listEntities(ctx, client, writer, "true", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Archived).To(BeTrue())
+}
+
+func TestListEntitiesTagFilter(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareEntityResponse(t, []CortexEntityElement{{Tag: "entity1"}, {Tag: "entity2"}}, 0, 1, 2)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err := listEntities(ctx, client, &EntityTagFilterWriter{writer, map[string]bool{"entity2": true}}, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Tag).To(Equal("entity2"))
+}
+
 func TestListEntitiesMultiPage(t *testing.T) {
 	g := NewWithT(t)
 	gh := ghttp.NewGHTTPWithGomega(g)
@@ -79,7 +130,7 @@ func TestListEntitiesMultiPage(t *testing.T) {
 
 	writer := NewSliceWriter[CortexEntityElement](100)
 
-	err := listEntities(ctx, client, writer, "false", "")
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
 	g.Expect(err).To(BeNil())
 
 	g.Expect(writer.Items).To(HaveLen(3))
@@ -88,6 +139,45 @@ func TestListEntitiesMultiPage(t *testing.T) {
 	g.Expect(writer.Items[2].Name).To(Equal("entity3"))
 }
 
+func TestListEntitiesCursor(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	respPage0Bytes, err := yaml.Marshal(CortexEntityResponse{
+		Entities:   []CortexEntityElement{{Name: "entity1"}},
+		NextCursor: "abc",
+	})
+	g.Expect(err).To(BeNil())
+	respPage1Bytes, err := yaml.Marshal(CortexEntityResponse{
+		Entities: []CortexEntityElement{{Name: "entity2"}},
+	})
+	g.Expect(err).To(BeNil())
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("page", "0"),
+			gh.RespondWith(http.StatusOK, respPage0Bytes, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("cursor", "abc"),
+			gh.RespondWith(http.StatusOK, respPage1Bytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err = listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].Name).To(Equal("entity1"))
+	g.Expect(writer.Items[1].Name).To(Equal("entity2"))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(2))
+}
+
 func TestListEntitiesError(t *testing.T) {
 	g := NewWithT(t)
 	gh := ghttp.NewGHTTPWithGomega(g)
@@ -103,7 +193,7 @@ func TestListEntitiesError(t *testing.T) {
 
 	writer := NewSliceWriter[CortexEntityElement](100)
 
-	err := listEntities(ctx, client, writer, "false", "")
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
 	g.Expect(err).ToNot(BeNil())
 	g.Expect(err.Error()).To(Equal("error from cortex API 500 Internal Server Error: {\"details\": \"fake error on page 0\"}"))
 }
@@ -120,11 +210,21 @@ func TestTableCortexEntity(t *testing.T) {
 	// Check list configuration.
 	g.Expect(table.List).ToNot(BeNil())
 	g.Expect(table.List.Hydrate).ToNot(BeNil())
-	g.Expect(table.List.KeyColumns).To(HaveLen(2))
+	g.Expect(table.List.KeyColumns).To(HaveLen(5))
 	g.Expect(table.List.KeyColumns[0].Name).To(Equal("archived"))
 	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Optional))
 	g.Expect(table.List.KeyColumns[1].Name).To(Equal("type"))
 	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[2].Name).To(Equal("query"))
+	g.Expect(table.List.KeyColumns[2].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[3].Name).To(Equal("tag"))
+	g.Expect(table.List.KeyColumns[3].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[4].Name).To(Equal("search"))
+	g.Expect(table.List.KeyColumns[4].Require).To(Equal(plugin.Optional))
+
+	// Per-row hydrate calls are limited.
+	g.Expect(table.HydrateConfig).To(HaveLen(1))
+	g.Expect(table.HydrateConfig[0].MaxConcurrency).To(Equal(MaxHydrateConcurrency))
 
 	// Define expected columns.
 	expectedColumns := []struct {
@@ -136,6 +236,8 @@ func TestTableCortexEntity(t *testing.T) {
 		{"description", proto.ColumnType_STRING},
 		{"type", proto.ColumnType_STRING},
 		{"parents", proto.ColumnType_JSON},
+		{"ancestors", proto.ColumnType_JSON},
+		{"hierarchy_path", proto.ColumnType_JSON},
 		{"groups", proto.ColumnType_JSON},
 		{"metadata", proto.ColumnType_JSON},
 		{"last_updated", proto.ColumnType_TIMESTAMP},
@@ -143,8 +245,13 @@ func TestTableCortexEntity(t *testing.T) {
 		{"archived", proto.ColumnType_BOOL},
 		{"repository", proto.ColumnType_STRING},
 		{"slack_channels", proto.ColumnType_JSON},
+		{"slack_notifications_enabled", proto.ColumnType_BOOL},
 		{"owner_teams", proto.ColumnType_JSON},
 		{"owner_individuals", proto.ColumnType_JSON},
+		{"effective_owners", proto.ColumnType_JSON},
+		{"query", proto.ColumnType_STRING},
+		{"search", proto.ColumnType_STRING},
+		{"error", proto.ColumnType_STRING},
 	}
 
 	// Check that the table has the expected columns.
@@ -154,3 +261,304 @@ func TestTableCortexEntity(t *testing.T) {
 		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
 	}
 }
+
+func TestEntityHierarchy(t *testing.T) {
+	g := NewWithT(t)
+
+	// service1 is in domain2 and domain3, domain2 is in domain1
+	entity := CortexEntityElement{
+		Tag: "service1",
+		Hierarchy: CortexEntityElementHierarchy{
+			Parents: []CortexEntityHierarchyNode{
+				{Tag: "domain2", Parents: []CortexEntityHierarchyNode{{Tag: "domain1"}}},
+				{Tag: "domain3", Parents: []CortexEntityHierarchyNode{{Tag: "domain1"}}},
+			},
+		},
+	}
+
+	g.Expect(entity.Ancestors()).To(Equal([]string{"domain2", "domain3", "domain1"}))
+	g.Expect(entity.HierarchyPath()).To(Equal([]string{"domain1", "domain2", "service1"}))
+}
+
+func TestEntityHierarchyNoParents(t *testing.T) {
+	g := NewWithT(t)
+
+	entity := CortexEntityElement{Tag: "domain1"}
+
+	g.Expect(entity.Ancestors()).To(BeEmpty())
+	g.Expect(entity.HierarchyPath()).To(Equal([]string{"domain1"}))
+}
+
+func TestGetEffectiveOwners(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	domain, err := yaml.Marshal(CortexEntityElement{Tag: "domain1", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{
+		{Tag: "platform", Inheritance: "APPEND"},
+		{Tag: "fallback-team", Inheritance: "FALLBACK"},
+		{Tag: "domain-only", Inheritance: "NONE"},
+	}}})
+	g.Expect(err).To(BeNil())
+	root, err := yaml.Marshal(CortexEntityElement{Tag: "root", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{
+		{Tag: "platform", Inheritance: "APPEND"},
+		{Tag: "sre", Inheritance: "APPEND"},
+	}}})
+	g.Expect(err).To(BeNil())
+
+	// Ancestors are fetched in parallel, so route by path rather than by request order
+	ctx, server, client := setupTestServerAndClient(t)
+	defer server.Close()
+	server.RouteToHandler("GET", "/api/v1/catalog/domain1", ghttp.CombineHandlers(
+		gh.VerifyFormKV("includeOwners", "true"),
+		gh.RespondWith(http.StatusOK, domain, nil),
+	))
+	server.RouteToHandler("GET", "/api/v1/catalog/root", gh.RespondWith(http.StatusOK, root, nil))
+
+	entity := CortexEntityElement{
+		Tag:       "service1",
+		Hierarchy: CortexEntityElementHierarchy{Parents: []CortexEntityHierarchyNode{{Tag: "domain1", Parents: []CortexEntityHierarchyNode{{Tag: "root"}}}}},
+		Owners:    CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team1"}}},
+	}
+	owners, err := getEffectiveOwners(ctx, client, entity)
+	g.Expect(err).To(BeNil())
+	g.Expect(owners).To(Equal([]string{"team1", "platform", "sre"}))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(2))
+}
+
+func TestGetEffectiveOwnersFallback(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	domain, err := yaml.Marshal(CortexEntityElement{Tag: "domain1", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{
+		{Tag: "fallback-team", Inheritance: "FALLBACK"},
+	}}})
+	g.Expect(err).To(BeNil())
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/domain1"),
+			gh.RespondWith(http.StatusOK, domain, nil),
+		),
+	)
+	defer server.Close()
+
+	entity := CortexEntityElement{
+		Tag:       "service1",
+		Hierarchy: CortexEntityElementHierarchy{Parents: []CortexEntityHierarchyNode{{Tag: "domain1"}}},
+	}
+	owners, err := getEffectiveOwners(ctx, client, entity)
+	g.Expect(err).To(BeNil())
+	g.Expect(owners).To(Equal([]string{"fallback-team"}))
+}
+
+func TestEntityIncludesForColumns(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntity()
+
+	g.Expect(entityIncludesForColumns(table, []string{"name", "tag", "type"})).To(Equal(EntityIncludes{}))
+	g.Expect(entityIncludesForColumns(table, []string{"tag", "links", "owner_teams"})).To(Equal(EntityIncludes{Links: true, Owners: true}))
+	g.Expect(entityIncludesForColumns(table, []string{"effective_owners"})).To(Equal(EntityIncludes{Owners: true, HierarchyFields: true}))
+
+	// Columns from metadata_columns need the metadata
+	err := addMetadataColumns(table, []MetadataColumn{{Key: "tier", Name: "tier", Type: proto.ColumnType_INT}}, func() *transform.ColumnTransforms {
+		return transform.FromField("Metadata").Transform(TagArrayToMap)
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(entityIncludesForColumns(table, []string{"tag", "tier"})).To(Equal(EntityIncludes{Metadata: true}))
+}
+
+func TestListEntitiesIncludes(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareEntityResponse(t, []CortexEntityElement{{Name: "entity1"}}, 0, 1, 1)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("includeLinks", "true"),
+			gh.VerifyFormKV("includeMetadata", "false"),
+			gh.VerifyFormKV("includeSlackChannels", "false"),
+			gh.VerifyFormKV("includeOwners", "false"),
+			gh.VerifyFormKV("includeHierarchyFields", "false"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err := listEntities(ctx, client, writer, "false", "", "", EntityIncludes{Links: true})
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+}
+
+func TestGetEntityByTag(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	entity, err := yaml.Marshal(CortexEntityElement{Tag: "service1", Type: "service"})
+	g.Expect(err).To(BeNil())
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1"),
+			gh.VerifyFormKV("includeLinks", "true"),
+			gh.RespondWith(http.StatusOK, entity, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/service1"),
+			gh.RespondWith(http.StatusOK, entity, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/missing"),
+			gh.RespondWith(http.StatusNotFound, "{}", nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err = getEntityByTag(ctx, client, writer, "service1", "false", "", EntityIncludes{Links: true})
+	g.Expect(err).To(BeNil())
+	// Filtered out by type
+	err = getEntityByTag(ctx, client, writer, "service1", "false", "domain", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+	// Unknown tags have no rows
+	err = getEntityByTag(ctx, client, writer, "missing", "false", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Tag).To(Equal("service1"))
+}
+
+func TestGetEntitiesByTag(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	// Each get waits for the other, so they only succeed when run in parallel
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	waitForBoth := func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Errorf("%s was not fetched in parallel with the other tag", r.URL.Path)
+		}
+	}
+	ctx, server, client := setupTestServerAndClient(t)
+	defer server.Close()
+	server.RouteToHandler("GET", "/api/v1/catalog/service1", ghttp.CombineHandlers(waitForBoth, gh.RespondWith(http.StatusOK, `{"tag": "service1", "type": "service"}`, nil)))
+	server.RouteToHandler("GET", "/api/v1/catalog/service2", ghttp.CombineHandlers(waitForBoth, gh.RespondWith(http.StatusOK, `{"tag": "service2", "type": "service"}`, nil)))
+
+	// The values of a tag IN (...) that steampipe didn't split are each fetched
+	tags := &proto.QualValue{Value: &proto.QualValue_ListValue{ListValue: &proto.QualValueList{Values: []*proto.QualValue{
+		{Value: &proto.QualValue_StringValue{StringValue: "service1"}},
+		{Value: &proto.QualValue_StringValue{StringValue: "service2"}},
+	}}}}
+	semaphore := make(chan struct{}, 2)
+	writer := NewSliceWriter[CortexEntityElement](100)
+	err := getEntitiesByTag(ctx, client, writer, semaphore, qualStringValues(tags), "false", "", EntityIncludes{})
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(ConsistOf(
+		HaveField("Tag", "service1"),
+		HaveField("Tag", "service2"),
+	))
+	g.Expect(semaphore).To(BeEmpty())
+
+	// A cancelled query doesn't wait for a slot
+	semaphore <- struct{}{}
+	semaphore <- struct{}{}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = getEntitiesByTag(cancelled, client, writer, semaphore, []string{"service1"}, "false", "", EntityIncludes{})
+	g.Expect(err).To(MatchError(context.Canceled))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(2))
+}
+
+func TestGetEntitiesByTagLimit(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t)
+	defer server.Close()
+	server.RouteToHandler("GET", "/api/v1/catalog/service1", gh.RespondWith(http.StatusOK, `{"tag": "service1", "type": "service"}`, nil))
+	// The second get is cancelled once the limit is hit by the first
+	server.RouteToHandler("GET", "/api/v1/catalog/service2", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	semaphore := make(chan struct{}, 2)
+	writer := NewSliceWriter[CortexEntityElement](1)
+	err := getEntitiesByTag(ctx, client, writer, semaphore, []string{"service1", "service2"}, "false", "", EntityIncludes{})
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Tag).To(Equal("service1"))
+}
+
+func TestCombineTypeQuals(t *testing.T) {
+	g := NewWithT(t)
+
+	in := &proto.Quals{Quals: []*proto.Qual{{
+		FieldName: "type",
+		Operator:  &proto.Qual_StringValue{StringValue: "="},
+		Value: &proto.QualValue{Value: &proto.QualValue_ListValue{ListValue: &proto.QualValueList{Values: []*proto.QualValue{
+			{Value: &proto.QualValue_StringValue{StringValue: "service"}},
+			{Value: &proto.QualValue_StringValue{StringValue: "domain"}},
+			{Value: &proto.QualValue_StringValue{StringValue: "rds"}},
+		}}}},
+	}}}
+
+	// The first value lists all the types, the others are skipped
+	types, skip := combineTypeQuals(in.Quals[0].Value.GetListValue().Values[0], in)
+	g.Expect(skip).To(BeFalse())
+	g.Expect(types).To(Equal("service,domain,rds"))
+	_, skip = combineTypeQuals(in.Quals[0].Value.GetListValue().Values[1], in)
+	g.Expect(skip).To(BeTrue())
+
+	// With another IN list steampipe doesn't split the calls, the list itself is the equals qual
+	types, skip = combineTypeQuals(in.Quals[0].Value, in)
+	g.Expect(skip).To(BeFalse())
+	g.Expect(types).To(Equal("service,domain,rds"))
+
+	// A single value is passed through
+	equals := &proto.Quals{Quals: []*proto.Qual{{
+		FieldName: "type",
+		Operator:  &proto.Qual_StringValue{StringValue: "="},
+		Value:     &proto.QualValue{Value: &proto.QualValue_StringValue{StringValue: "service"}},
+	}}}
+	types, skip = combineTypeQuals(equals.Quals[0].Value, equals)
+	g.Expect(skip).To(BeFalse())
+	g.Expect(types).To(Equal("service"))
+
+	g.Expect(containsType("service,domain", "domain")).To(BeTrue())
+	g.Expect(containsType("service,domain", "rds")).To(BeFalse())
+}
+
+func TestListEntitiesSearch(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareEntityResponse(t, []CortexEntityElement{{Name: "payments-api"}}, 0, 1, 1)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.VerifyFormKV("query", "payments"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+
+	err := listEntities(ctx, client, writer, "false", "", "payments", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+}
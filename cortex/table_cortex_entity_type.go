@@ -0,0 +1,153 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Response elements for the /catalog/definitions endpoint
+type CortexEntityTypeResponse struct {
+	Definitions []CortexEntityType `yaml:"definitions"`
+	Page        int                `yaml:"page"`
+	TotalPages  int                `yaml:"totalPages"`
+	Total       int                `yaml:"total"`
+	NextCursor  string             `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexEntityTypeResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
+}
+
+type CortexEntityType struct {
+	Type        string                 `yaml:"type"`
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Source      string                 `yaml:"source"`
+	Schema      map[string]interface{} `yaml:"schema"`
+
+	// Enriched data
+	BuiltIn bool `yaml:"-"`
+}
+
+// Entity types every workspace has, the definitions endpoint only returns custom types
+var BuiltInEntityTypes = []CortexEntityType{
+	{Type: "service", Name: "Service", BuiltIn: true},
+	{Type: "domain", Name: "Domain", BuiltIn: true},
+	{Type: "team", Name: "Team", BuiltIn: true},
+}
+
+// Counts are returned in the entities list response, only the total is used
+type CortexEntityCountResponse struct {
+	Total int `yaml:"total"`
+}
+
+func tableCortexEntityType() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_entity_type",
+		Description: "Cortex entity types, built-in and custom, with the number of entities of each type.",
+		List: &plugin.ListConfig{
+			Hydrate: listEntityTypesHydrator,
+		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{Func: getEntityTypeCountHydrator, MaxConcurrency: MaxHydrateConcurrency},
+		},
+		Columns: []*plugin.Column{
+			{Name: "type", Type: proto.ColumnType_STRING, Description: "The type identifier used by entities, e.g. service."},
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Pretty name of the type."},
+			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description of the type."},
+			{Name: "source", Type: proto.ColumnType_STRING, Description: "Where a custom type is defined, e.g. UI or GITOPS."},
+			{Name: "built_in", Type: proto.ColumnType_BOOL, Description: "True for types every workspace has.", Transform: transform.FromField("BuiltIn")},
+			{Name: "schema", Type: proto.ColumnType_JSON, Description: "JSON schema of the type's metadata."},
+			{Name: "entity_count", Type: proto.ColumnType_INT, Description: "Number of unarchived entities of this type.", Hydrate: getEntityTypeCountHydrator, Transform: transform.FromValue()},
+		},
+	}
+}
+
+func listEntityTypesHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listEntityTypes(ctx, client, &hydratorWriter)
+}
+
+func listEntityTypes(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+
+	for _, entityType := range BuiltInEntityTypes {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, entityType)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+
+	request := func() *req.Request {
+		return client.Get("/api/v1/catalog/definitions")
+	}
+	return cortexapi.Paginate(ctx, request, func(response CortexEntityTypeResponse) (bool, error) {
+		logger.Debug("listEntityTypes", "totalPages", response.TotalPages, "total", response.Total)
+		for _, result := range response.Definitions {
+			// send the item to steampipe
+			writer.StreamListItem(ctx, result)
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if writer.RowsRemaining(ctx) == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// All custom entity type definitions, used to build a table per type
+func getEntityTypeDefinitions(ctx context.Context, client *req.Client) ([]CortexEntityType, error) {
+	var definitions []CortexEntityType
+	request := func() *req.Request {
+		return client.Get("/api/v1/catalog/definitions")
+	}
+	err := cortexapi.Paginate(ctx, request, func(response CortexEntityTypeResponse) (bool, error) {
+		definitions = append(definitions, response.Definitions...)
+		return true, nil
+	})
+	return definitions, err
+}
+
+func getEntityTypeCountHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	entityType := h.Item.(CortexEntityType)
+	return getEntityTypeCount(ctx, client, entityType.Type)
+}
+
+// Number of unarchived entities of the type, a single entity page is requested just for the total
+func getEntityTypeCount(ctx context.Context, client *req.Client, entityType string) (int, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/catalog").
+		SetQueryParam("types", entityType).
+		SetQueryParam("includeArchived", "false").
+		SetQueryParam("pageSize", "1").
+		SetQueryParam("page", "0").
+		Do(ctx)
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getEntityTypeCount", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return 0, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var response CortexEntityCountResponse
+	err := resp.Into(&response)
+	if err != nil {
+		logger.Error("getEntityTypeCount", "Error", err)
+		return 0, err
+	}
+	return response.Total, nil
+}
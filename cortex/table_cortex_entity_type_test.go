@@ -0,0 +1,120 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"gopkg.in/yaml.v3"
+)
+
+func prepareEntityTypeResponse(t *testing.T, definitions []CortexEntityType, page, totalPages, total int) []byte {
+	t.Helper()
+	response := CortexEntityTypeResponse{
+		Definitions: definitions,
+		Page:        page,
+		TotalPages:  totalPages,
+		Total:       total,
+	}
+	responseBytes, err := yaml.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func TestTableCortexEntityType(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexEntityType()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_entity_type"))
+	g.Expect(table.Description).To(Equal("Cortex entity types, built-in and custom, with the number of entities of each type."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Per-row hydrate calls are limited.
+	g.Expect(table.HydrateConfig).To(HaveLen(1))
+	g.Expect(table.HydrateConfig[0].MaxConcurrency).To(Equal(MaxHydrateConcurrency))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"type", proto.ColumnType_STRING},
+		{"name", proto.ColumnType_STRING},
+		{"description", proto.ColumnType_STRING},
+		{"source", proto.ColumnType_STRING},
+		{"built_in", proto.ColumnType_BOOL},
+		{"schema", proto.ColumnType_JSON},
+		{"entity_count", proto.ColumnType_INT},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListEntityTypes(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	page0 := prepareEntityTypeResponse(t, []CortexEntityType{
+		{Type: "rds", Name: "RDS", Source: "GITOPS", Schema: map[string]interface{}{"type": "object"}},
+	}, 0, 2, 2)
+	page1 := prepareEntityTypeResponse(t, []CortexEntityType{
+		{Type: "queue", Name: "Queue", Source: "UI"},
+	}, 1, 2, 2)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/definitions"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, page0, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog/definitions"),
+			gh.RespondWith(http.StatusOK, page1, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityType](100)
+
+	err := listEntityTypes(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(len(BuiltInEntityTypes) + 2))
+	g.Expect(writer.Items[0].Type).To(Equal("service"))
+	g.Expect(writer.Items[0].BuiltIn).To(BeTrue())
+	g.Expect(writer.Items[3].Type).To(Equal("rds"))
+	g.Expect(writer.Items[3].BuiltIn).To(BeFalse())
+	g.Expect(writer.Items[3].Schema).To(HaveKeyWithValue("type", "object"))
+	g.Expect(writer.Items[4].Type).To(Equal("queue"))
+}
+
+func TestGetEntityTypeCount(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog", "types=rds&includeArchived=false&pageSize=1&page=0"),
+			gh.RespondWith(http.StatusOK, "{\"entities\": [], \"total\": 42}", nil),
+		),
+	)
+	defer server.Close()
+
+	count, err := getEntityTypeCount(ctx, client, "rds")
+	g.Expect(err).To(BeNil())
+	g.Expect(count).To(Equal(42))
+}
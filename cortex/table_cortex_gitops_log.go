@@ -0,0 +1,111 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Response elements for the /gitops-logs endpoint
+type CortexGitopsLogResponse struct {
+	Logs       []CortexGitopsLog `yaml:"logs"`
+	Page       int               `yaml:"page"`
+	TotalPages int               `yaml:"totalPages"`
+	Total      int               `yaml:"total"`
+	NextCursor string            `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexGitopsLogResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
+}
+
+type CortexGitopsLog struct {
+	Commit      string                    `yaml:"commit"`
+	DateCreated string                    `yaml:"dateCreated"`
+	Repository  CortexGitopsLogRepository `yaml:"repository"`
+	Files       []CortexGitopsLogFile     `yaml:"files"`
+}
+
+type CortexGitopsLogRepository struct {
+	Provider       string `yaml:"provider"`
+	RepositoryName string `yaml:"repositoryName"`
+}
+
+type CortexGitopsLogFile struct {
+	FileName  string                `yaml:"fileName"`
+	Operation string                `yaml:"operation"`
+	Entity    CortexGitopsLogEntity `yaml:"entity"`
+	Errors    []string              `yaml:"errors"`
+}
+
+type CortexGitopsLogEntity struct {
+	Tag string `yaml:"tag"`
+}
+
+// Used to represent the data we want to return in the table
+type CortexGitopsLogRow struct {
+	Log  CortexGitopsLog
+	File CortexGitopsLogFile
+}
+
+// Files with errors failed to sync
+func (r CortexGitopsLogRow) Status() string {
+	if len(r.File.Errors) > 0 {
+		return "FAILED"
+	}
+	return "SUCCESS"
+}
+
+func tableCortexGitopsLog() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_gitops_log",
+		Description: "Cortex GitOps logs, one row per file processed.",
+		List: &plugin.ListConfig{
+			Hydrate: listGitopsLogsHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "repository", Type: proto.ColumnType_STRING, Description: "Repository the file was read from.", Transform: transform.FromField("Log.Repository.RepositoryName")},
+			{Name: "provider", Type: proto.ColumnType_STRING, Description: "Git provider of the repository.", Transform: transform.FromField("Log.Repository.Provider")},
+			{Name: "commit", Type: proto.ColumnType_STRING, Description: "Commit that was processed.", Transform: transform.FromField("Log.Commit")},
+			{Name: "date_created", Type: proto.ColumnType_TIMESTAMP, Description: "When the commit was processed.", Transform: transform.FromField("Log.DateCreated").Transform(ToUTCTimestamp)},
+			{Name: "file_name", Type: proto.ColumnType_STRING, Description: "Path of the file.", Transform: transform.FromField("File.FileName")},
+			{Name: "operation", Type: proto.ColumnType_STRING, Description: "What was done with the file, e.g. CREATED or UPDATED.", Transform: transform.FromField("File.Operation")},
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity in the file.", Transform: transform.FromField("File.Entity.Tag")},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "SUCCESS, or FAILED when the file has errors.", Transform: transform.FromP(transform.MethodValue, "Status")},
+			{Name: "errors", Type: proto.ColumnType_JSON, Description: "List of errors from processing the file.", Transform: transform.FromField("File.Errors")},
+		},
+	}
+}
+
+func listGitopsLogsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listGitopsLogs(ctx, client, &hydratorWriter)
+}
+
+func listGitopsLogs(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+
+	request := func() *req.Request {
+		return client.Get("/api/v1/gitops-logs")
+	}
+	return cortexapi.Paginate(ctx, request, func(response CortexGitopsLogResponse) (bool, error) {
+		logger.Debug("listGitopsLogs", "totalPages", response.TotalPages, "total", response.Total)
+		for _, log := range response.Logs {
+			for _, file := range log.Files {
+				// send the item to steampipe
+				writer.StreamListItem(ctx, CortexGitopsLogRow{Log: log, File: file})
+				// Context can be cancelled due to manual cancellation or the limit has been hit
+				if writer.RowsRemaining(ctx) == 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,122 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"gopkg.in/yaml.v3"
+)
+
+func prepareGitopsLogResponse(t *testing.T, logs []CortexGitopsLog, page, totalPages, total int) []byte {
+	t.Helper()
+	response := CortexGitopsLogResponse{
+		Logs:       logs,
+		Page:       page,
+		TotalPages: totalPages,
+		Total:      total,
+	}
+	responseBytes, err := yaml.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func TestTableCortexGitopsLog(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexGitopsLog()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_gitops_log"))
+	g.Expect(table.Description).To(Equal("Cortex GitOps logs, one row per file processed."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"repository", proto.ColumnType_STRING},
+		{"provider", proto.ColumnType_STRING},
+		{"commit", proto.ColumnType_STRING},
+		{"date_created", proto.ColumnType_TIMESTAMP},
+		{"file_name", proto.ColumnType_STRING},
+		{"operation", proto.ColumnType_STRING},
+		{"entity_tag", proto.ColumnType_STRING},
+		{"status", proto.ColumnType_STRING},
+		{"errors", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListGitopsLogs(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	page0 := prepareGitopsLogResponse(t, []CortexGitopsLog{
+		{Commit: "abc", Repository: CortexGitopsLogRepository{RepositoryName: "org/service1"}, Files: []CortexGitopsLogFile{
+			{FileName: "cortex.yaml", Operation: "UPDATED", Entity: CortexGitopsLogEntity{Tag: "service1"}},
+			{FileName: ".cortex/scorecards/prod.yaml", Errors: []string{"invalid rule"}},
+		}},
+	}, 0, 2, 2)
+	page1 := prepareGitopsLogResponse(t, []CortexGitopsLog{
+		{Commit: "def", Files: []CortexGitopsLogFile{{FileName: "cortex.yaml"}}},
+	}, 1, 2, 2)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/gitops-logs"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, page0, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/gitops-logs"),
+			gh.RespondWith(http.StatusOK, page1, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexGitopsLogRow](100)
+
+	err := listGitopsLogs(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(3))
+	g.Expect(writer.Items[0].Log.Commit).To(Equal("abc"))
+	g.Expect(writer.Items[0].File.Entity.Tag).To(Equal("service1"))
+	g.Expect(writer.Items[0].Status()).To(Equal("SUCCESS"))
+	g.Expect(writer.Items[1].Status()).To(Equal("FAILED"))
+	g.Expect(writer.Items[2].Log.Commit).To(Equal("def"))
+}
+
+func TestListGitopsLogsError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/gitops-logs"),
+			gh.RespondWith(http.StatusForbidden, "{\"details\": \"forbidden\"}", nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexGitopsLogRow](100)
+
+	err := listGitopsLogs(ctx, client, writer)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 403 Forbidden: {\"details\": \"forbidden\"}"))
+}
@@ -0,0 +1,93 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+type CortexIPAllowlistRequest struct {
+	Entries []CortexIPAllowlistEntry `json:"entries"`
+}
+
+type CortexIPAllowlistEntry struct {
+	Address string `json:"address"`
+}
+
+// Used to represent the data we want to return in the table
+type CortexIPAllowlistValidationRow struct {
+	IP        string
+	Permitted bool
+	Message   string
+}
+
+func tableCortexIPAllowlistValidation() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_ip_allowlist_validation",
+		Description: "Dry-run validation of an IP address or CIDR range against the Cortex IP allowlist.",
+		List: &plugin.ListConfig{
+			Hydrate: listIPAllowlistValidationHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "ip", Require: plugin.Required},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "ip", Type: proto.ColumnType_STRING, Description: "IP address or CIDR range to validate."},
+			{Name: "permitted", Type: proto.ColumnType_BOOL, Description: "Whether an allowlist of the ip would be accepted.", Transform: transform.FromField("Permitted")},
+			{Name: "message", Type: proto.ColumnType_STRING, Description: "Reason the ip was rejected, null when permitted.", Transform: transform.FromField("Message").NullIfZero()},
+		},
+	}
+}
+
+func listIPAllowlistValidationHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	ip := d.EqualsQuals["ip"].GetStringValue()
+	logger.Info("listIPAllowlistValidationHydrator", "ip", ip)
+	return nil, listIPAllowlistValidation(ctx, client, &writer, ip)
+}
+
+// Submit the ip to the validate endpoint, the allowlist itself is not changed.
+func listIPAllowlistValidation(ctx context.Context, client *req.Client, writer HydratorWriter, ip string) error {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Post("/api/v1/ip-allowlist/validate").
+		SetBody(CortexIPAllowlistRequest{Entries: []CortexIPAllowlistEntry{{Address: ip}}}).
+		Do(ctx)
+
+	if resp.Err != nil {
+		logger.Error("listIPAllowlistValidation", "ip", ip, "Error", resp.Err)
+		return resp.Err
+	}
+
+	row := CortexIPAllowlistValidationRow{IP: ip, Permitted: true}
+	// A rejected entry is reported as a bad request
+	if resp.StatusCode == http.StatusBadRequest {
+		var errorResponse CortexValidationErrorResponse
+		err := resp.Into(&errorResponse)
+		if err != nil {
+			logger.Error("listIPAllowlistValidation", "ip", ip, "Error", err)
+			return err
+		}
+		row.Permitted = false
+		row.Message = errorResponse.Details
+		if row.Message == "" {
+			row.Message = errorResponse.Message
+		}
+	} else if resp.IsErrorState() {
+		logger.Error("listIPAllowlistValidation", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return cortexapi.NewError(resp)
+	}
+
+	// send the item to steampipe
+	writer.StreamListItem(ctx, row)
+	return nil
+}
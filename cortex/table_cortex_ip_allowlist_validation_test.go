@@ -0,0 +1,90 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+func TestTableCortexIPAllowlistValidation(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexIPAllowlistValidation()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_ip_allowlist_validation"))
+	g.Expect(table.Description).To(Equal("Dry-run validation of an IP address or CIDR range against the Cortex IP allowlist."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("ip"))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"ip", proto.ColumnType_STRING},
+		{"permitted", proto.ColumnType_BOOL},
+		{"message", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListIPAllowlistValidation(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/ip-allowlist/validate"),
+			gh.VerifyJSON(`{"entries": [{"address": "10.0.0.0/8"}]}`),
+			gh.RespondWith(http.StatusOK, `{"entries": [{"address": "10.0.0.0/8"}]}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/ip-allowlist/validate"),
+			gh.RespondWith(http.StatusBadRequest, `{"message": "Bad request", "details": "Allowlist would block the current IP"}`),
+		),
+		gh.RespondWith(http.StatusForbidden, `{"message": "forbidden"}`),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexIPAllowlistValidationRow](100)
+	err := listIPAllowlistValidation(ctx, client, writer, "10.0.0.0/8")
+	g.Expect(err).To(BeNil())
+	err = listIPAllowlistValidation(ctx, client, writer, "192.168.0.1")
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(Equal([]CortexIPAllowlistValidationRow{
+		{IP: "10.0.0.0/8", Permitted: true},
+		{IP: "192.168.0.1", Permitted: false, Message: "Allowlist would block the current IP"},
+	}))
+
+	// Other errors fail the query
+	err = listIPAllowlistValidation(ctx, client, writer, "192.168.0.1")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestIPAllowlistValidationPermitted(t *testing.T) {
+	g := NewWithT(t)
+	column := getColumn(tableCortexIPAllowlistValidation(), "permitted")
+
+	// A rejected ip is false rather than null, so `where not permitted` finds it
+	for _, permitted := range []bool{true, false} {
+		value, err := column.Transform.Execute(context.Background(), &transform.TransformData{HydrateItem: CortexIPAllowlistValidationRow{IP: "192.168.0.1", Permitted: permitted}, ColumnName: "permitted"})
+		g.Expect(err).To(BeNil())
+		g.Expect(value).To(Equal(permitted))
+	}
+}
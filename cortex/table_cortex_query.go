@@ -0,0 +1,65 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexQueryRow struct {
+	JobID  string
+	Status string
+	Item   CortexQueryResultItem
+}
+
+func tableCortexQuery() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_query",
+		Description: "Cortex CQL queries api.",
+		List: &plugin.ListConfig{
+			Hydrate: listQueryResultsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "query", Require: plugin.Required},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "query", Type: proto.ColumnType_STRING, Description: "The CQL query to run.", Transform: transform.FromQual("query")},
+			{Name: "job_id", Type: proto.ColumnType_STRING, Description: "Id of the query job.", Transform: transform.FromField("JobID")},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "Final status of the query job."},
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "The x-cortex-tag of the entity.", Transform: transform.FromField("Item.Tag")},
+			{Name: "entity_name", Type: proto.ColumnType_STRING, Description: "Pretty name of the entity.", Transform: transform.FromField("Item.Name")},
+			{Name: "entity_type", Type: proto.ColumnType_STRING, Description: "Entity Type.", Transform: transform.FromField("Item.Type")},
+			{Name: "result", Type: proto.ColumnType_JSON, Description: "Result of the query for the entity.", Transform: transform.FromField("Item.Result")},
+		},
+	}
+}
+
+func listQueryResultsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	query := d.EqualsQuals["query"].GetStringValue()
+	logger.Info("listQueryResultsHydrator", "query", query)
+	return nil, listQueryResults(ctx, client, &hydratorWriter, query, config)
+}
+
+func listQueryResults(ctx context.Context, client *req.Client, writer HydratorWriter, query string, config *SteampipeConfig) error {
+	job, err := RunQuery(ctx, client, query, config.GetQueryPollInterval(), config.GetQueryTimeout())
+	if err != nil {
+		return err
+	}
+	for _, item := range job.Result {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, CortexQueryRow{JobID: job.JobID, Status: job.Status, Item: item})
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package cortex
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Latencies kept per endpoint for the percentiles, older calls are dropped
+const MaxRecordedLatencies = 1000
+
+type callStats struct {
+	calls      int
+	errors     int
+	bytes      int64
+	total      time.Duration
+	latencies  []time.Duration
+	lastCalled time.Time
+}
+
+// Used to represent the data we want to return in the table
+type CortexQueryDiagnosticsRow struct {
+	Endpoint     string
+	CallCount    int
+	ErrorCount   int
+	TotalBytes   int64
+	AvgLatencyMs float64
+	P95LatencyMs float64
+	MaxLatencyMs float64
+	LastCalledAt time.Time
+}
+
+// Statistics of the calls to each endpoint since the plugin started, by connection.
+// Clients are created per hydrate call, so this is kept for the plugin process rather than the client.
+var endpointStats = struct {
+	sync.Mutex
+	byConnection map[string]map[string]*callStats
+}{byConnection: make(map[string]map[string]*callStats)}
+
+// Record the size and latency of a response, endpoints are the method and path template, e.g. GET /api/v1/catalog/{tag}
+func recordCallStats(connection string, resp *req.Response) {
+	if resp.Request == nil || resp.Response == nil {
+		return
+	}
+	endpoint := resp.Request.Method + " " + resp.Request.RawURL
+
+	endpointStats.Lock()
+	defer endpointStats.Unlock()
+	endpoints, ok := endpointStats.byConnection[connection]
+	if !ok {
+		endpoints = make(map[string]*callStats)
+		endpointStats.byConnection[connection] = endpoints
+	}
+	stats, ok := endpoints[endpoint]
+	if !ok {
+		stats = &callStats{}
+		endpoints[endpoint] = stats
+	}
+	latency := resp.TotalTime()
+	stats.calls++
+	if resp.IsErrorState() {
+		stats.errors++
+	}
+	// Streamed bodies haven't been read yet, they are counted as they are read
+	if resp.Bytes() == nil && resp.Body != nil {
+		resp.Body = &countingBody{ReadCloser: resp.Body, stats: stats}
+	} else {
+		stats.bytes += int64(len(resp.Bytes()))
+	}
+	stats.total += latency
+	stats.lastCalled = time.Now().UTC()
+	stats.latencies = append(stats.latencies, latency)
+	if len(stats.latencies) > MaxRecordedLatencies {
+		stats.latencies = stats.latencies[len(stats.latencies)-MaxRecordedLatencies:]
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	stats *callStats
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	endpointStats.Lock()
+	b.stats.bytes += int64(n)
+	endpointStats.Unlock()
+	return n, err
+}
+
+func (s *callStats) row(endpoint string) CortexQueryDiagnosticsRow {
+	latencies := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	row := CortexQueryDiagnosticsRow{
+		Endpoint:     endpoint,
+		CallCount:    s.calls,
+		ErrorCount:   s.errors,
+		TotalBytes:   s.bytes,
+		LastCalledAt: s.lastCalled,
+	}
+	if len(latencies) > 0 {
+		// Nearest rank
+		p95 := latencies[(len(latencies)*95+99)/100-1]
+		row.AvgLatencyMs = milliseconds(s.total / time.Duration(s.calls))
+		row.P95LatencyMs = milliseconds(p95)
+		row.MaxLatencyMs = milliseconds(latencies[len(latencies)-1])
+	}
+	return row
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func tableCortexQueryDiagnostics() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_query_diagnostics",
+		Description: "Cortex API calls made by the plugin for each endpoint.",
+		List: &plugin.ListConfig{
+			Hydrate: listQueryDiagnosticsHydrator,
+		},
+		// The statistics change with every call, a cached result would be stale
+		Cache: &plugin.TableCacheOptions{Enabled: false},
+		Columns: []*plugin.Column{
+			{Name: "endpoint", Type: proto.ColumnType_STRING, Description: "Method and path of the endpoint, e.g. GET /api/v1/catalog/{tag}."},
+			{Name: "call_count", Type: proto.ColumnType_INT, Description: "Number of calls, retries are counted separately.", Transform: transform.FromField("CallCount")},
+			{Name: "error_count", Type: proto.ColumnType_INT, Description: "Number of calls with an error status.", Transform: transform.FromField("ErrorCount")},
+			{Name: "total_bytes", Type: proto.ColumnType_INT, Description: "Size of all the response bodies.", Transform: transform.FromField("TotalBytes")},
+			{Name: "avg_latency_ms", Type: proto.ColumnType_DOUBLE, Description: "Average latency of a call in milliseconds.", Transform: transform.FromField("AvgLatencyMs")},
+			{Name: "p95_latency_ms", Type: proto.ColumnType_DOUBLE, Description: "95th percentile latency of the last 1000 calls in milliseconds.", Transform: transform.FromField("P95LatencyMs")},
+			{Name: "max_latency_ms", Type: proto.ColumnType_DOUBLE, Description: "Highest latency of the last 1000 calls in milliseconds.", Transform: transform.FromField("MaxLatencyMs")},
+			{Name: "last_called_at", Type: proto.ColumnType_TIMESTAMP, Description: "Time of the last call."},
+		},
+	}
+}
+
+func listQueryDiagnosticsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	writer := QueryDataWriter{d}
+	return nil, listQueryDiagnostics(ctx, &writer, config.connectionKey())
+}
+
+// Stream the statistics of the calls made by the connection since the plugin started
+func listQueryDiagnostics(ctx context.Context, writer HydratorWriter, connection string) error {
+	endpointStats.Lock()
+	rows := make([]CortexQueryDiagnosticsRow, 0, len(endpointStats.byConnection[connection]))
+	for endpoint, stats := range endpointStats.byConnection[connection] {
+		rows = append(rows, stats.row(endpoint))
+	}
+	endpointStats.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Endpoint < rows[j].Endpoint })
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestTableCortexQueryDiagnostics(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexQueryDiagnostics()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_query_diagnostics"))
+	g.Expect(table.Description).To(Equal("Cortex API calls made by the plugin for each endpoint."))
+	g.Expect(table.Cache.Enabled).To(BeFalse())
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"endpoint", proto.ColumnType_STRING},
+		{"call_count", proto.ColumnType_INT},
+		{"error_count", proto.ColumnType_INT},
+		{"total_bytes", proto.ColumnType_INT},
+		{"avg_latency_ms", proto.ColumnType_DOUBLE},
+		{"p95_latency_ms", proto.ColumnType_DOUBLE},
+		{"max_latency_ms", proto.ColumnType_DOUBLE},
+		{"last_called_at", proto.ColumnType_TIMESTAMP},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListQueryDiagnostics(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	entity := `{"tag": "service1", "type": "service"}`
+	ctx, server, client := setupTestServerAndClient(t,
+		gh.RespondWith(http.StatusOK, entity),
+		gh.RespondWith(http.StatusOK, entity),
+		gh.RespondWith(http.StatusNotFound, "{}"),
+	)
+	defer server.Close()
+
+	before := time.Now()
+	for _, tag := range []string{"service1", "service2"} {
+		_, err := getEntity(ctx, client, tag, EntityIncludes{})
+		g.Expect(err).To(BeNil())
+	}
+	_, err := client.Get("/api/v1/catalog").Do(ctx).ToBytes()
+	g.Expect(err).To(BeNil())
+
+	writer := NewSliceWriter[CortexQueryDiagnosticsRow](100)
+	err = listQueryDiagnostics(ctx, writer, server.URL())
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	catalog, entities := writer.Items[0], writer.Items[1]
+
+	g.Expect(catalog.Endpoint).To(Equal("GET /api/v1/catalog"))
+	g.Expect(catalog.CallCount).To(Equal(1))
+	g.Expect(catalog.ErrorCount).To(Equal(1))
+	g.Expect(catalog.TotalBytes).To(Equal(int64(2)))
+
+	g.Expect(entities.Endpoint).To(Equal("GET /api/v1/catalog/{tag}"))
+	g.Expect(entities.CallCount).To(Equal(2))
+	g.Expect(entities.ErrorCount).To(Equal(0))
+	g.Expect(entities.TotalBytes).To(Equal(int64(2 * len(entity))))
+	g.Expect(entities.P95LatencyMs).To(BeNumerically(">", 0))
+	g.Expect(entities.MaxLatencyMs).To(Equal(entities.P95LatencyMs))
+	g.Expect(entities.AvgLatencyMs).To(BeNumerically("<=", entities.MaxLatencyMs))
+	g.Expect(entities.LastCalledAt).To(BeTemporally(">=", before))
+}
+
+func TestListQueryDiagnosticsPerConnection(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`),
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`),
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`),
+	)
+
+	// Two connections to the same workspace with different keys
+	calls := map[string]int{"cortex_readonly": 1, "cortex_admin": 2}
+	for name, count := range calls {
+		config := GetConfig(&plugin.Connection{Name: name, Config: *NewSteampipeConfig(name+"_key", server.URL())})
+		getCredentialCheck(server.URL(), name+"_key").done = true
+		client := CortexHTTPClient(ctx, config)
+		for range count {
+			_, err := getEntity(ctx, client, "service1", EntityIncludes{})
+			g.Expect(err).To(BeNil())
+		}
+	}
+
+	for name, count := range calls {
+		writer := NewSliceWriter[CortexQueryDiagnosticsRow](100)
+		err := listQueryDiagnostics(ctx, writer, name)
+		g.Expect(err).To(BeNil())
+		g.Expect(writer.Items).To(HaveLen(1))
+		g.Expect(writer.Items[0].CallCount).To(Equal(count))
+	}
+}
+
+func TestCallStatsP95(t *testing.T) {
+	g := NewWithT(t)
+	stats := &callStats{}
+	for i := 1; i <= 100; i++ {
+		latency := time.Duration(i) * time.Millisecond
+		stats.calls++
+		stats.total += latency
+		stats.latencies = append(stats.latencies, latency)
+	}
+
+	row := stats.row("GET /api/v1/catalog")
+	g.Expect(row.P95LatencyMs).To(Equal(95.0))
+	g.Expect(row.MaxLatencyMs).To(Equal(100.0))
+	g.Expect(row.AvgLatencyMs).To(Equal(50.5))
+}
+
+func TestListQueryDiagnosticsStreamedBytes(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	catalog := `{"entities": [{"tag": "service1", "type": "service"}], "page": 0, "totalPages": 1}`
+	ctx, server, client := setupTestServerAndClient(t, gh.RespondWith(http.StatusOK, catalog))
+	defer server.Close()
+
+	err := listEntities(ctx, client, NewSliceWriter[CortexEntityElement](100), "false", "", "", EntityIncludes{})
+	g.Expect(err).To(BeNil())
+
+	writer := NewSliceWriter[CortexQueryDiagnosticsRow](100)
+	err = listQueryDiagnostics(ctx, writer, server.URL())
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].TotalBytes).To(Equal(int64(len(catalog))))
+}
@@ -0,0 +1,76 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexQuery(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexQuery()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_query"))
+	g.Expect(table.Description).To(Equal("Cortex CQL queries api."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("query"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"query", proto.ColumnType_STRING},
+		{"job_id", proto.ColumnType_STRING},
+		{"status", proto.ColumnType_STRING},
+		{"entity_tag", proto.ColumnType_STRING},
+		{"entity_name", proto.ColumnType_STRING},
+		{"entity_type", proto.ColumnType_STRING},
+		{"result", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListQueryResults(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("POST", "/api/v1/queries"),
+			gh.RespondWith(http.StatusOK, prepareQueryJobResponse(t, CortexQueryJob{
+				JobID:  "job1",
+				Status: QueryStatusDone,
+				Result: []CortexQueryResultItem{{Tag: "service1"}, {Tag: "service2"}},
+			}), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexQueryRow](100)
+
+	err := listQueryResults(ctx, client, writer, "git != null", NewSteampipeConfig("fake_api_key", server.URL()))
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].JobID).To(Equal("job1"))
+	g.Expect(writer.Items[0].Item.Tag).To(Equal("service1"))
+	g.Expect(writer.Items[1].Item.Tag).To(Equal("service2"))
+}
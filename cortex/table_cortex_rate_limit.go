@@ -0,0 +1,143 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Response headers with the rate limit state of an endpoint
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// Used to represent the data we want to return in the table
+type CortexRateLimitRow struct {
+	Endpoint       string
+	Limit          *int
+	Remaining      *int
+	ResetTime      *time.Time
+	LastStatus     int
+	ThrottledCount int
+	RequestCount   int
+	ObservedAt     time.Time
+}
+
+// The last rate limit state seen for each endpoint, by connection. Clients are created
+// per hydrate call, so this is kept for the plugin process rather than the client.
+var rateLimits = struct {
+	sync.Mutex
+	byConnection map[string]map[string]*CortexRateLimitRow
+}{byConnection: make(map[string]map[string]*CortexRateLimitRow)}
+
+// Record the rate limit headers of a response, endpoints are the method and path template, e.g. GET /api/v1/catalog/{tag}
+func recordRateLimit(connection string, resp *req.Response) {
+	if resp.Request == nil || resp.Response == nil {
+		return
+	}
+	endpoint := resp.Request.Method + " " + resp.Request.RawURL
+	now := time.Now().UTC()
+
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+	endpoints, ok := rateLimits.byConnection[connection]
+	if !ok {
+		endpoints = make(map[string]*CortexRateLimitRow)
+		rateLimits.byConnection[connection] = endpoints
+	}
+	row, ok := endpoints[endpoint]
+	if !ok {
+		row = &CortexRateLimitRow{Endpoint: endpoint}
+		endpoints[endpoint] = row
+	}
+	row.RequestCount++
+	row.LastStatus = resp.StatusCode
+	row.ObservedAt = now
+	if resp.StatusCode == http.StatusTooManyRequests {
+		row.ThrottledCount++
+	}
+	if limit, err := strconv.Atoi(resp.GetHeader(RateLimitLimitHeader)); err == nil {
+		row.Limit = &limit
+	}
+	if remaining, err := strconv.Atoi(resp.GetHeader(RateLimitRemainingHeader)); err == nil {
+		row.Remaining = &remaining
+	}
+	if reset, ok := parseRateLimitReset(resp.GetHeader(RateLimitResetHeader), now); ok {
+		row.ResetTime = &reset
+	} else if reset, ok := parseRateLimitReset(resp.GetHeader(RetryAfterHeader), now); ok {
+		row.ResetTime = &reset
+	}
+}
+
+// Reset headers are either a unix time or the seconds until the reset
+func parseRateLimitReset(value string, now time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+	// Seconds until a reset are never this large
+	if seconds > 1_000_000_000 {
+		return time.Unix(seconds, 0).UTC(), true
+	}
+	return now.Add(time.Duration(seconds) * time.Second), true
+}
+
+func tableCortexRateLimit() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_rate_limit",
+		Description: "Cortex API rate limits seen by the plugin for each endpoint.",
+		List: &plugin.ListConfig{
+			Hydrate: listRateLimitsHydrator,
+		},
+		// The state changes with every call, a cached result would be stale
+		Cache: &plugin.TableCacheOptions{Enabled: false},
+		Columns: []*plugin.Column{
+			{Name: "endpoint", Type: proto.ColumnType_STRING, Description: "Method and path of the endpoint, e.g. GET /api/v1/catalog/{tag}."},
+			{Name: "limit", Type: proto.ColumnType_INT, Description: "Requests allowed in the current window, from the last response."},
+			{Name: "remaining", Type: proto.ColumnType_INT, Description: "Requests left in the current window, from the last response."},
+			{Name: "reset_time", Type: proto.ColumnType_TIMESTAMP, Description: "When the window resets, from the last response."},
+			{Name: "last_status", Type: proto.ColumnType_INT, Description: "HTTP status of the last response.", Transform: transform.FromField("LastStatus")},
+			{Name: "throttled_count", Type: proto.ColumnType_INT, Description: "Number of responses with a 429 status.", Transform: transform.FromField("ThrottledCount")},
+			{Name: "request_count", Type: proto.ColumnType_INT, Description: "Number of responses seen.", Transform: transform.FromField("RequestCount")},
+			{Name: "observed_at", Type: proto.ColumnType_TIMESTAMP, Description: "Time of the last response."},
+		},
+	}
+}
+
+func listRateLimitsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	writer := QueryDataWriter{d}
+	return nil, listRateLimits(ctx, &writer, config.connectionKey())
+}
+
+// Stream the rate limits seen by the connection, only calls made since the plugin started are included
+func listRateLimits(ctx context.Context, writer HydratorWriter, connection string) error {
+	rateLimits.Lock()
+	rows := make([]CortexRateLimitRow, 0, len(rateLimits.byConnection[connection]))
+	for _, row := range rateLimits.byConnection[connection] {
+		rows = append(rows, *row)
+	}
+	rateLimits.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Endpoint < rows[j].Endpoint })
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+func TestTableCortexRateLimit(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexRateLimit()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_rate_limit"))
+	g.Expect(table.Description).To(Equal("Cortex API rate limits seen by the plugin for each endpoint."))
+	g.Expect(table.Cache.Enabled).To(BeFalse())
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"endpoint", proto.ColumnType_STRING},
+		{"limit", proto.ColumnType_INT},
+		{"remaining", proto.ColumnType_INT},
+		{"reset_time", proto.ColumnType_TIMESTAMP},
+		{"last_status", proto.ColumnType_INT},
+		{"throttled_count", proto.ColumnType_INT},
+		{"request_count", proto.ColumnType_INT},
+		{"observed_at", proto.ColumnType_TIMESTAMP},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListRateLimits(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	entity := `{"tag": "service1", "type": "service"}`
+	ctx, server, client := setupTestServerAndClient(t,
+		gh.RespondWith(http.StatusOK, entity, http.Header{RateLimitLimitHeader: []string{"1000"}, RateLimitRemainingHeader: []string{"999"}, RateLimitResetHeader: []string{"30"}}),
+		gh.RespondWith(http.StatusOK, entity, http.Header{RateLimitLimitHeader: []string{"1000"}, RateLimitRemainingHeader: []string{"998"}, RateLimitResetHeader: []string{"1893456000"}}),
+		gh.RespondWith(http.StatusTooManyRequests, "{}", http.Header{RetryAfterHeader: []string{"10"}}),
+	)
+	defer server.Close()
+
+	for _, tag := range []string{"service1", "service2"} {
+		_, err := getEntity(ctx, client, tag, EntityIncludes{})
+		g.Expect(err).To(BeNil())
+	}
+	before := time.Now()
+	_, err := client.Get("/api/v1/catalog").Do(ctx).ToBytes()
+	g.Expect(err).To(BeNil())
+
+	writer := NewSliceWriter[CortexRateLimitRow](100)
+	err = listRateLimits(ctx, writer, server.URL())
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	throttled, entities := writer.Items[0], writer.Items[1]
+
+	g.Expect(throttled.Endpoint).To(Equal("GET /api/v1/catalog"))
+	g.Expect(throttled.ThrottledCount).To(Equal(1))
+	g.Expect(throttled.LastStatus).To(Equal(http.StatusTooManyRequests))
+	g.Expect(throttled.Limit).To(BeNil())
+	g.Expect(*throttled.ResetTime).To(BeTemporally("~", before.Add(10*time.Second), 5*time.Second))
+
+	g.Expect(entities.Endpoint).To(Equal("GET /api/v1/catalog/{tag}"))
+	g.Expect(entities.RequestCount).To(Equal(2))
+	g.Expect(*entities.Limit).To(Equal(1000))
+	g.Expect(*entities.Remaining).To(Equal(998))
+	g.Expect(*entities.ResetTime).To(Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestListRateLimitsPerConnection(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`, http.Header{RateLimitRemainingHeader: []string{"10"}}),
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`, http.Header{RateLimitRemainingHeader: []string{"500"}}),
+	)
+
+	// Two connections to the same workspace with keys that have their own limits
+	for _, name := range []string{"cortex_readonly", "cortex_admin"} {
+		config := GetConfig(&plugin.Connection{Name: name, Config: *NewSteampipeConfig(name+"_key", server.URL())})
+		getCredentialCheck(server.URL(), name+"_key").done = true
+		_, err := getEntity(ctx, CortexHTTPClient(ctx, config), "service1", EntityIncludes{})
+		g.Expect(err).To(BeNil())
+	}
+
+	for name, remaining := range map[string]int{"cortex_readonly": 10, "cortex_admin": 500} {
+		writer := NewSliceWriter[CortexRateLimitRow](100)
+		err := listRateLimits(ctx, writer, name)
+		g.Expect(err).To(BeNil())
+		g.Expect(writer.Items).To(HaveLen(1))
+		g.Expect(*writer.Items[0].Remaining).To(Equal(remaining))
+	}
+}
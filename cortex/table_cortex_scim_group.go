@@ -0,0 +1,118 @@
+package cortex
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// The SCIM API pages by a 1-based start index rather than page numbers
+const SCIMPageSize = 100
+
+type CortexSCIMGroupsResponse struct {
+	TotalResults int               `yaml:"totalResults"`
+	Resources    []CortexSCIMGroup `yaml:"Resources"`
+}
+
+type CortexSCIMGroup struct {
+	ID          string                  `yaml:"id"`
+	DisplayName string                  `yaml:"displayName"`
+	ExternalID  string                  `yaml:"externalId"`
+	Members     []CortexSCIMGroupMember `yaml:"members"`
+
+	// Enriched data
+	TeamTag string `yaml:"-"`
+}
+
+type CortexSCIMGroupMember struct {
+	Value   string `yaml:"value"`
+	Display string `yaml:"display"`
+}
+
+func (g CortexSCIMGroup) MemberCount() int {
+	return len(g.Members)
+}
+
+func tableCortexSCIMGroup() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_scim_group",
+		Description: "Groups provisioned in Cortex by an identity provider over SCIM.",
+		List: &plugin.ListConfig{
+			Hydrate: listSCIMGroupsHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "id", Type: proto.ColumnType_STRING, Description: "SCIM id of the group.", Transform: transform.FromField("ID")},
+			{Name: "display_name", Type: proto.ColumnType_STRING, Description: "Display name of the group in the identity provider."},
+			{Name: "external_id", Type: proto.ColumnType_STRING, Description: "Id of the group in the identity provider.", Transform: transform.FromField("ExternalID").NullIfZero()},
+			{Name: "member_count", Type: proto.ColumnType_INT, Description: "Number of members provisioned in the group.", Transform: transform.FromP(transform.MethodValue, "MemberCount")},
+			{Name: "members", Type: proto.ColumnType_JSON, Description: "Members of the group, each with the SCIM user id as value and display name."},
+			{Name: "team_tag", Type: proto.ColumnType_STRING, Description: "Tag of the Cortex team synced from the group, null when no team uses the group.", Transform: transform.FromField("TeamTag").NullIfZero()},
+		},
+	}
+}
+
+func listSCIMGroupsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listSCIMGroups(ctx, client, &hydratorWriter)
+}
+
+func listSCIMGroups(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+
+	// Teams synced from an IdP name the group they come from
+	teams, err := getTeams(ctx, client, "true")
+	if err != nil {
+		return err
+	}
+	teamTags := make(map[string]string)
+	for _, team := range teams {
+		if team.IDPGroup.Group != "" {
+			teamTags[team.IDPGroup.Group] = team.Tag
+		}
+	}
+
+	startIndex := 1
+	for {
+		resp := client.
+			Get("/scim/v2/Groups").
+			SetQueryParam("startIndex", strconv.Itoa(startIndex)).
+			SetQueryParam("count", strconv.Itoa(SCIMPageSize)).
+			Do(ctx)
+
+		// Check for HTTP errors
+		if resp.IsErrorState() {
+			logger.Error("listSCIMGroups", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+			return cortexapi.NewError(resp)
+		}
+
+		// Unmarshal the response and check for unmarshal errors
+		var response CortexSCIMGroupsResponse
+		err := resp.Into(&response)
+		if err != nil {
+			logger.Error("listSCIMGroups", "Error", err)
+			return err
+		}
+
+		for _, group := range response.Resources {
+			group.TeamTag = teamTags[group.DisplayName]
+			// send the item to steampipe
+			writer.StreamListItem(ctx, group)
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if writer.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+
+		startIndex += len(response.Resources)
+		if len(response.Resources) == 0 || startIndex > response.TotalResults {
+			return nil
+		}
+	}
+}
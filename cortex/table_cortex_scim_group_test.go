@@ -0,0 +1,79 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func TestTableCortexSCIMGroup(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexSCIMGroup()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_scim_group"))
+	g.Expect(table.Description).To(Equal("Groups provisioned in Cortex by an identity provider over SCIM."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"id", proto.ColumnType_STRING},
+		{"display_name", proto.ColumnType_STRING},
+		{"external_id", proto.ColumnType_STRING},
+		{"member_count", proto.ColumnType_INT},
+		{"members", proto.ColumnType_JSON},
+		{"team_tag", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListSCIMGroups(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	scimHeader := http.Header{}
+	scimHeader.Set("Content-Type", "application/scim+json")
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams", "includeTeamsWithoutMembers=true"),
+			gh.RespondWith(http.StatusOK, `{"teams": [{"teamTag": "platform", "idpGroup": {"group": "Platform", "provider": "OKTA"}}, {"teamTag": "manual"}]}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/scim/v2/Groups", "startIndex=1&count=100"),
+			gh.RespondWith(http.StatusOK, `{"totalResults": 3, "startIndex": 1, "Resources": [{"id": "g1", "displayName": "Platform", "externalId": "00g1", "members": [{"value": "u1", "display": "Alice"}, {"value": "u2", "display": "Bob"}]}, {"id": "g2", "displayName": "Security"}]}`, scimHeader),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/scim/v2/Groups", "startIndex=3&count=100"),
+			gh.RespondWith(http.StatusOK, `{"totalResults": 3, "startIndex": 3, "Resources": [{"id": "g3", "displayName": "Data"}]}`, scimHeader),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexSCIMGroup](100)
+	err := listSCIMGroups(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(3))
+	g.Expect(writer.Items[0].ID).To(Equal("g1"))
+	g.Expect(writer.Items[0].MemberCount()).To(Equal(2))
+	g.Expect(writer.Items[0].TeamTag).To(Equal("platform"))
+	g.Expect(writer.Items[1].MemberCount()).To(Equal(0))
+	g.Expect(writer.Items[1].TeamTag).To(BeEmpty())
+	g.Expect(writer.Items[2].DisplayName).To(Equal("Data"))
+}
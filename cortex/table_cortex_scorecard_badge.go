@@ -0,0 +1,128 @@
+package cortex
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Static badges are rendered by shields.io, nothing about the entity is sent other than the badge text
+const ShieldsBadgeURL = "https://img.shields.io/badge"
+
+// Used to represent the data we want to return in the table
+type CortexScorecardBadgeRow struct {
+	ScorecardTag  string
+	ScorecardName string
+	EntityTag     string
+	Level         *CortexLevel
+}
+
+// Shields.io static badge of the scorecard name and current level, coloured like the level
+func (r CortexScorecardBadgeRow) BadgeURL() string {
+	message, color := "none", "lightgrey"
+	if r.Level != nil {
+		message = r.Level.Name
+		if r.Level.Color != "" {
+			color = strings.TrimPrefix(r.Level.Color, "#")
+		}
+	}
+	return fmt.Sprintf("%s/%s-%s-%s", ShieldsBadgeURL, shieldsEscape(r.ScorecardName), shieldsEscape(message), url.PathEscape(color))
+}
+
+func (r CortexScorecardBadgeRow) BadgeMarkdown() string {
+	return fmt.Sprintf("![%s](%s)", r.ScorecardName, r.BadgeURL())
+}
+
+// Shields.io splits badge text on dashes, literal dashes and underscores are doubled and spaces are underscores
+func shieldsEscape(text string) string {
+	text = strings.ReplaceAll(text, "-", "--")
+	text = strings.ReplaceAll(text, "_", "__")
+	text = strings.ReplaceAll(text, " ", "_")
+	return url.PathEscape(text)
+}
+
+func tableCortexScorecardBadge() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_scorecard_badge",
+		Description: "Badges of the current level of each entity in a Cortex scorecard.",
+		List: &plugin.ListConfig{
+			Hydrate: listScorecardBadgesHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "scorecard_tag", Require: plugin.Required},
+				{Name: "entity_tag", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "scorecard_tag", Type: proto.ColumnType_STRING, Description: "Scorecard tag."},
+			{Name: "scorecard_name", Type: proto.ColumnType_STRING, Description: "Scorecard name."},
+			{Name: "entity_tag", Type: proto.ColumnType_STRING, Description: "Entity tag."},
+			{Name: "level_name", Type: proto.ColumnType_STRING, Description: "Highest level of the ladder the entity passes every rule of, and every level below. Null before the first level.", Transform: transform.FromField("Level.Name")},
+			{Name: "level_rank", Type: proto.ColumnType_INT, Description: "Number of the current level, 1 is the first level of the ladder.", Transform: transform.FromField("Level.Number")},
+			{Name: "level_color", Type: proto.ColumnType_STRING, Description: "Color of the current level.", Transform: transform.FromField("Level.Color")},
+			{Name: "badge_url", Type: proto.ColumnType_STRING, Description: "URL of a shields.io SVG badge of the current level.", Transform: transform.FromP(transform.MethodValue, "BadgeURL")},
+			{Name: "badge_markdown", Type: proto.ColumnType_STRING, Description: "Markdown image of the badge, for READMEs and wikis.", Transform: transform.FromP(transform.MethodValue, "BadgeMarkdown")},
+		},
+	}
+}
+
+func listScorecardBadgesHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
+	entityTag := ""
+	if d.EqualsQuals["entity_tag"] != nil {
+		entityTag = d.EqualsQuals["entity_tag"].GetStringValue()
+	}
+	logger.Info("listScorecardBadgesHydrator", "scorecardTag", scorecardTag, "entityTag", entityTag)
+	return nil, listScorecardBadges(ctx, client, &writer, scorecardTag, entityTag)
+}
+
+func listScorecardBadges(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string, entityTag string) error {
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
+	if err != nil {
+		return err
+	}
+	rules := make(map[string]*CortexRuleInfo)
+	for _, rule := range scorecard.Rules {
+		rules[rule.Identifier] = rule
+	}
+	levels := make([]CortexLevel, 0, len(scorecard.Levels))
+	for _, level := range scorecard.Levels {
+		levels = append(levels, level.Level)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Number < levels[j].Number })
+
+	return getScorecardScores(ctx, client, scorecardTag, entityTag, func(result *CortexServiceScore) (bool, error) {
+		row := CortexScorecardBadgeRow{
+			ScorecardTag:  scorecardTag,
+			ScorecardName: scorecard.Name,
+			EntityTag:     result.Service.Tag,
+			Level:         currentLevel(levels, failedLevels(result.Score, rules)),
+		}
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		return writer.RowsRemaining(ctx) != 0, nil
+	})
+}
+
+// The last of the ordered levels reached before the first failed level, nil if the first level failed
+func currentLevel(levels []CortexLevel, failed map[string]bool) *CortexLevel {
+	var current *CortexLevel
+	for i := range levels {
+		if failed[levels[i].Name] {
+			break
+		}
+		current = &levels[i]
+	}
+	return current
+}
@@ -0,0 +1,119 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func TestTableCortexScorecardBadge(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexScorecardBadge()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_scorecard_badge"))
+	g.Expect(table.Description).To(Equal("Badges of the current level of each entity in a Cortex scorecard."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(2))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("entity_tag"))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"scorecard_tag", proto.ColumnType_STRING},
+		{"scorecard_name", proto.ColumnType_STRING},
+		{"entity_tag", proto.ColumnType_STRING},
+		{"level_name", proto.ColumnType_STRING},
+		{"level_rank", proto.ColumnType_INT},
+		{"level_color", proto.ColumnType_STRING},
+		{"badge_url", proto.ColumnType_STRING},
+		{"badge_markdown", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListScorecardBadges(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	scorecard := CortexScorecard{
+		Name: "Production Readiness",
+		Rules: []*CortexRuleInfo{
+			{Identifier: "rule1", LevelName: "Bronze", Weight: 1},
+			{Identifier: "rule2", LevelName: "Silver", Weight: 1},
+			{Identifier: "rule3", LevelName: "Gold", Weight: 1},
+		},
+		// Levels are not necessarily in ladder order
+		Levels: []*CortexScorecardLevel{
+			{Level: CortexLevel{Name: "Silver", Number: 2, Color: "#c0c0c0"}},
+			{Level: CortexLevel{Name: "Bronze", Number: 1, Color: "#cd7f32"}},
+			{Level: CortexLevel{Name: "Gold", Number: 3, Color: "#ffd700"}},
+		},
+	}
+	scores := []*CortexServiceScore{
+		{
+			Service: &CortexEntityElement{Tag: "service1"},
+			Score: &CortexScore{Rules: []*CortexRuleScore{
+				{Identifier: "rule1", Score: 1},
+				{Identifier: "rule2", Score: 1},
+				{Identifier: "rule3", Score: 0},
+			}},
+		},
+		{
+			// Passing a higher level doesn't count once a lower one fails
+			Service: &CortexEntityElement{Tag: "service2"},
+			Score: &CortexScore{Rules: []*CortexRuleScore{
+				{Identifier: "rule1", Score: 0},
+				{Identifier: "rule2", Score: 1},
+				{Identifier: "rule3", Score: 1},
+			}},
+		},
+	}
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores, 0, 1, 2), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexScorecardBadgeRow](100)
+	err := listScorecardBadges(ctx, client, writer, "tag1", "")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].EntityTag).To(Equal("service1"))
+	g.Expect(writer.Items[0].Level.Name).To(Equal("Silver"))
+	g.Expect(writer.Items[0].BadgeURL()).To(Equal("https://img.shields.io/badge/Production_Readiness-Silver-c0c0c0"))
+	g.Expect(writer.Items[0].BadgeMarkdown()).To(Equal("![Production Readiness](https://img.shields.io/badge/Production_Readiness-Silver-c0c0c0)"))
+	g.Expect(writer.Items[1].EntityTag).To(Equal("service2"))
+	g.Expect(writer.Items[1].Level).To(BeNil())
+	g.Expect(writer.Items[1].BadgeURL()).To(Equal("https://img.shields.io/badge/Production_Readiness-none-lightgrey"))
+}
+
+func TestScorecardBadgeURLEscaping(t *testing.T) {
+	g := NewWithT(t)
+	row := CortexScorecardBadgeRow{ScorecardName: "DORA_metrics - 2024", Level: &CortexLevel{Name: "Level 1/2"}}
+	g.Expect(row.BadgeURL()).To(Equal("https://img.shields.io/badge/DORA__metrics_--_2024-Level_1%2F2-lightgrey"))
+}
@@ -0,0 +1,120 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexScorecardComplianceRow struct {
+	ScorecardTag       string
+	ScorecardName      string
+	Level              CortexLevel
+	EntityCount        int
+	PassingEntityCount int
+}
+
+// Share of the scored entities passing every rule of the level, 0 when nothing was scored
+func (r CortexScorecardComplianceRow) PassRate() float64 {
+	if r.EntityCount == 0 {
+		return 0
+	}
+	return float64(r.PassingEntityCount) / float64(r.EntityCount)
+}
+
+func tableCortexScorecardCompliance() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_scorecard_compliance",
+		Description: "Cortex scorecard compliance per level.",
+		List: &plugin.ListConfig{
+			Hydrate: listScorecardComplianceHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "scorecard_tag", Require: plugin.Required},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "scorecard_tag", Type: proto.ColumnType_STRING, Description: "Scorecard tag."},
+			{Name: "scorecard_name", Type: proto.ColumnType_STRING, Description: "Scorecard name."},
+			{Name: "level_name", Type: proto.ColumnType_STRING, Description: "Level name.", Transform: transform.FromField("Level.Name")},
+			{Name: "level_rank", Type: proto.ColumnType_INT, Description: "Level number, 1 is the first level of the ladder.", Transform: transform.FromField("Level.Number")},
+			{Name: "entity_count", Type: proto.ColumnType_INT, Description: "Number of entities scored.", Transform: transform.FromField("EntityCount")},
+			{Name: "passing_entity_count", Type: proto.ColumnType_INT, Description: "Number of entities passing every rule of the level.", Transform: transform.FromField("PassingEntityCount")},
+			{Name: "pass_rate", Type: proto.ColumnType_DOUBLE, Description: "Share of entities passing every rule of the level, between 0 and 1.", Transform: transform.FromP(transform.MethodValue, "PassRate")},
+		},
+	}
+}
+
+func listScorecardComplianceHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
+	logger.Info("listScorecardComplianceHydrator", "scorecardTag", scorecardTag)
+	return nil, listScorecardCompliance(ctx, client, &writer, scorecardTag)
+}
+
+func listScorecardCompliance(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string) error {
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
+	if err != nil {
+		return err
+	}
+	rules := make(map[string]*CortexRuleInfo)
+	for _, rule := range scorecard.Rules {
+		rules[rule.Identifier] = rule
+	}
+
+	// An entity passes a level when none of the rules in that level failed
+	entityCount := 0
+	passing := make(map[string]int)
+	err = getScorecardScores(ctx, client, scorecardTag, "", func(result *CortexServiceScore) (bool, error) {
+		entityCount++
+		failed := failedLevels(result.Score, rules)
+		for _, level := range scorecard.Levels {
+			if !failed[level.Level.Name] {
+				passing[level.Level.Name]++
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, level := range scorecard.Levels {
+		row := CortexScorecardComplianceRow{
+			ScorecardTag:       scorecardTag,
+			ScorecardName:      scorecard.Name,
+			Level:              level.Level,
+			EntityCount:        entityCount,
+			PassingEntityCount: passing[level.Level.Name],
+		}
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Names of the levels where the entity failed at least one rule
+func failedLevels(score *CortexScore, rules map[string]*CortexRuleInfo) map[string]bool {
+	failed := make(map[string]bool)
+	for _, ruleScore := range score.Rules {
+		ruleInfo, ok := rules[ruleScore.Identifier]
+		if !ok {
+			continue
+		}
+		row := CortexScorecardScoreRow{RuleScore: ruleScore, RuleInfo: ruleInfo}
+		if !row.IsRulePass() {
+			failed[ruleInfo.LevelName] = true
+		}
+	}
+	return failed
+}
@@ -0,0 +1,120 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexScorecardCompliance(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexScorecardCompliance()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_scorecard_compliance"))
+	g.Expect(table.Description).To(Equal("Cortex scorecard compliance per level."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"scorecard_tag", proto.ColumnType_STRING},
+		{"scorecard_name", proto.ColumnType_STRING},
+		{"level_name", proto.ColumnType_STRING},
+		{"level_rank", proto.ColumnType_INT},
+		{"entity_count", proto.ColumnType_INT},
+		{"passing_entity_count", proto.ColumnType_INT},
+		{"pass_rate", proto.ColumnType_DOUBLE},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListScorecardCompliance(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	scorecard := CortexScorecard{
+		Name: "Production Readiness",
+		Rules: []*CortexRuleInfo{
+			{Identifier: "rule1", LevelName: "Bronze", Weight: 1},
+			{Identifier: "rule2", LevelName: "Bronze", Weight: 1},
+			{Identifier: "rule3", LevelName: "Silver", Weight: 5},
+		},
+		Levels: []*CortexScorecardLevel{
+			{Level: CortexLevel{Name: "Bronze", Number: 1}},
+			{Level: CortexLevel{Name: "Silver", Number: 2}},
+		},
+	}
+	scores := []*CortexServiceScore{
+		{
+			Service: &CortexEntityElement{Tag: "service1"},
+			Score: &CortexScore{Rules: []*CortexRuleScore{
+				{Identifier: "rule1", Score: 1},
+				{Identifier: "rule2", Score: 1},
+				{Identifier: "rule3", Score: 0},
+			}},
+		},
+		{
+			Service: &CortexEntityElement{Tag: "service2"},
+			Score: &CortexScore{Rules: []*CortexRuleScore{
+				{Identifier: "rule1", Score: 1},
+				{Identifier: "rule2", Score: 0},
+				{Identifier: "rule3", Score: 5},
+			}},
+		},
+	}
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores[:1], 0, 2, 2), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores[1:], 1, 2, 2), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexScorecardComplianceRow](100)
+
+	err := listScorecardCompliance(ctx, client, writer, "tag1")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].Level.Name).To(Equal("Bronze"))
+	g.Expect(writer.Items[0].EntityCount).To(Equal(2))
+	g.Expect(writer.Items[0].PassingEntityCount).To(Equal(1))
+	g.Expect(writer.Items[0].PassRate()).To(Equal(0.5))
+	g.Expect(writer.Items[1].Level.Name).To(Equal("Silver"))
+	g.Expect(writer.Items[1].PassingEntityCount).To(Equal(1))
+	g.Expect(writer.Items[1].ScorecardName).To(Equal("Production Readiness"))
+}
+
+func TestScorecardCompliancePassRateNoEntities(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(CortexScorecardComplianceRow{}.PassRate()).To(Equal(0.0))
+}
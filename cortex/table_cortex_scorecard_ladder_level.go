@@ -0,0 +1,79 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexScorecardLadderLevelRow struct {
+	ScorecardTag  string
+	ScorecardName string
+	Level         CortexLevel
+	RuleCount     int
+}
+
+func tableCortexScorecardLadderLevel() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_scorecard_ladder_level",
+		Description: "Cortex scorecard ladder levels.",
+		List: &plugin.ListConfig{
+			Hydrate: listScorecardLadderLevelsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "scorecard_tag", Require: plugin.Required},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "scorecard_tag", Type: proto.ColumnType_STRING, Description: "Scorecard tag."},
+			{Name: "scorecard_name", Type: proto.ColumnType_STRING, Description: "Scorecard name."},
+			{Name: "level_name", Type: proto.ColumnType_STRING, Description: "Level name.", Transform: transform.FromField("Level.Name")},
+			{Name: "level_rank", Type: proto.ColumnType_INT, Description: "Level number, 1 is the first level of the ladder.", Transform: transform.FromField("Level.Number")},
+			{Name: "level_color", Type: proto.ColumnType_STRING, Description: "Level color.", Transform: transform.FromField("Level.Color")},
+			{Name: "level_description", Type: proto.ColumnType_STRING, Description: "Level description.", Transform: transform.FromField("Level.Description")},
+			{Name: "rule_count", Type: proto.ColumnType_INT, Description: "Number of rules in the level.", Transform: transform.FromField("RuleCount")},
+		},
+	}
+}
+
+func listScorecardLadderLevelsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
+	logger.Info("listScorecardLadderLevelsHydrator", "scorecardTag", scorecardTag)
+	return nil, listScorecardLadderLevels(ctx, client, &writer, scorecardTag)
+}
+
+func listScorecardLadderLevels(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string) error {
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
+	if err != nil {
+		return err
+	}
+
+	// Count the rules in each level
+	ruleCounts := make(map[string]int)
+	for _, rule := range scorecard.Rules {
+		ruleCounts[rule.LevelName]++
+	}
+
+	for _, level := range scorecard.Levels {
+		row := CortexScorecardLadderLevelRow{
+			ScorecardTag:  scorecardTag,
+			ScorecardName: scorecard.Name,
+			Level:         level.Level,
+			RuleCount:     ruleCounts[level.Level.Name],
+		}
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
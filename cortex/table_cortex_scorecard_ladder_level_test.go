@@ -0,0 +1,109 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexScorecardLadderLevel(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexScorecardLadderLevel()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_scorecard_ladder_level"))
+	g.Expect(table.Description).To(Equal("Cortex scorecard ladder levels."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"scorecard_tag", proto.ColumnType_STRING},
+		{"scorecard_name", proto.ColumnType_STRING},
+		{"level_name", proto.ColumnType_STRING},
+		{"level_rank", proto.ColumnType_INT},
+		{"level_color", proto.ColumnType_STRING},
+		{"level_description", proto.ColumnType_STRING},
+		{"rule_count", proto.ColumnType_INT},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListScorecardLadderLevels(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	scorecard := CortexScorecard{
+		Name: "Production Readiness",
+		Rules: []*CortexRuleInfo{
+			{Identifier: "rule1", LevelName: "Bronze"},
+			{Identifier: "rule2", LevelName: "Bronze"},
+			{Identifier: "rule3", LevelName: "Silver"},
+		},
+		Levels: []*CortexScorecardLevel{
+			{Level: CortexLevel{Name: "Bronze", Number: 1, Color: "#cd7f32"}},
+			{Level: CortexLevel{Name: "Silver", Number: 2, Color: "#c0c0c0"}},
+			{Level: CortexLevel{Name: "Gold", Number: 3, Color: "#ffd700"}},
+		},
+	}
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexScorecardLadderLevelRow](100)
+
+	err := listScorecardLadderLevels(ctx, client, writer, "tag1")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(3))
+	g.Expect(writer.Items[0].ScorecardTag).To(Equal("tag1"))
+	g.Expect(writer.Items[0].ScorecardName).To(Equal("Production Readiness"))
+	g.Expect(writer.Items[0].Level.Color).To(Equal("#cd7f32"))
+	g.Expect(writer.Items[0].RuleCount).To(Equal(2))
+	g.Expect(writer.Items[1].RuleCount).To(Equal(1))
+	g.Expect(writer.Items[2].RuleCount).To(Equal(0))
+}
+
+func TestListScorecardLadderLevelsError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusNotFound, "{\"details\": \"fake error on scorecard\"}", nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexScorecardLadderLevelRow](100)
+
+	err := listScorecardLadderLevels(ctx, client, writer, "tag1")
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 404 Not Found: {\"details\": \"fake error on scorecard\"}"))
+}
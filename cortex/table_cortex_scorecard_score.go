@@ -2,10 +2,9 @@ package cortex
 
 import (
 	"context"
-	"fmt"
-	"strconv"
 
 	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -17,26 +16,45 @@ type CortexScorecardResponse struct {
 }
 
 type CortexScorecard struct {
+	Tag    string                  `yaml:"tag"`
+	Name   string                  `yaml:"name"`
+	Filter CortexScorecardFilter   `yaml:"filter,omitempty"`
 	Levels []*CortexScorecardLevel `yaml:"levels"`
 	Rules  []*CortexRuleInfo       `yaml:"rules"`
 }
 
+// Which entities a scorecard or rule applies to
+type CortexScorecardFilter struct {
+	Query  string                `yaml:"query,omitempty"`
+	Types  CortexFilterInclusion `yaml:"types,omitempty"`
+	Groups CortexFilterInclusion `yaml:"groups,omitempty"`
+}
+
+type CortexFilterInclusion struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
 type CortexScorecardLevel struct {
 	Level CortexLevel `yaml:"level"`
 }
 
 type CortexLevel struct {
-	Name   string `yaml:"name"`
-	Number int    `yaml:"number"`
+	Name        string `yaml:"name"`
+	Number      int    `yaml:"number"`
+	Color       string `yaml:"color,omitempty"`
+	Description string `yaml:"description,omitempty"`
 }
 
 type CortexRuleInfo struct {
-	Description   string `yaml:"description"`
-	EffectiveFrom string `yaml:"effectiveFrom"`
-	Identifier    string `yaml:"identifier"`
-	LevelName     string `yaml:"levelName"`
-	Title         string `yaml:"title"`
-	Weight        int    `yaml:"weight"`
+	Description   string                `yaml:"description"`
+	EffectiveFrom string                `yaml:"effectiveFrom"`
+	Expression    string                `yaml:"expression"`
+	Filter        CortexScorecardFilter `yaml:"filter,omitempty"`
+	Identifier    string                `yaml:"identifier"`
+	LevelName     string                `yaml:"levelName"`
+	Title         string                `yaml:"title"`
+	Weight        int                   `yaml:"weight"`
 
 	// Not in the API response, but used to enrich the data
 	LevelNumber int `yaml:"-"`
@@ -50,6 +68,11 @@ type CortexScorecardScoreResponse struct {
 	Page          int                   `yaml:"page"`
 	TotalPages    int                   `yaml:"totalPages"`
 	Total         int                   `yaml:"total"`
+	NextCursor    string                `yaml:"nextCursor,omitempty"`
+}
+
+func (r CortexScorecardScoreResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
 }
 
 type CortexServiceScore struct {
@@ -70,12 +93,13 @@ type CortexRuleScore struct {
 
 // Used to represent the data we want to return in the table
 type CortexScorecardScoreRow struct {
-	ScorecardName string
-	ScorecardTag  string
-	LastEvaluated string
-	Service       *CortexEntityElement
-	RuleScore     *CortexRuleScore
-	RuleInfo      *CortexRuleInfo
+	ScorecardName   string
+	ScorecardTag    string
+	ScorecardFilter CortexScorecardFilter
+	LastEvaluated   string
+	Service         *CortexEntityElement
+	RuleScore       *CortexRuleScore
+	RuleInfo        *CortexRuleInfo
 }
 
 func (r *CortexScorecardScoreRow) IsRulePass() bool {
@@ -90,6 +114,7 @@ func tableCortexScorecardScore() *plugin.Table {
 			Hydrate: listScorecardScoresHydrator,
 			KeyColumns: []*plugin.KeyColumn{
 				{Name: "scorecard_tag", Require: plugin.Required},
+				{Name: "service_tag", Require: plugin.Optional},
 			},
 		},
 		Columns: []*plugin.Column{
@@ -98,57 +123,51 @@ func tableCortexScorecardScore() *plugin.Table {
 			{Name: "service_tag", Type: proto.ColumnType_STRING, Description: "Service type.", Transform: transform.FromField("Service.Tag")},
 			{Name: "service_name", Type: proto.ColumnType_STRING, Description: "Service name.", Transform: transform.FromField("Service.Name")},
 			{Name: "service_groups", Type: proto.ColumnType_JSON, Description: "Service groups.", Transform: transform.FromField("Service.Groups")},
-			{Name: "last_evaluated", Type: proto.ColumnType_STRING, Description: "Last evaluated."},
+			{Name: "last_evaluated", Type: proto.ColumnType_TIMESTAMP, Description: "Last evaluated.", Transform: transform.FromField("LastEvaluated").Transform(ToUTCTimestamp)},
 			{Name: "rule_identifier", Type: proto.ColumnType_STRING, Description: "Rule identifier.", Transform: transform.FromField("RuleScore.Identifier")},
 			{Name: "rule_title", Type: proto.ColumnType_STRING, Description: "Rule title.", Transform: transform.FromField("RuleInfo.Title")},
 			{Name: "rule_description", Type: proto.ColumnType_STRING, Description: "Rule description.", Transform: transform.FromField("RuleInfo.Description")},
 			{Name: "rule_expression", Type: proto.ColumnType_STRING, Description: "Rule expression.", Transform: transform.FromField("RuleScore.Expression")},
-			{Name: "rule_effective_from", Type: proto.ColumnType_STRING, Description: "Rule effective from.", Transform: transform.FromField("RuleInfo.EffectiveFrom")},
+			{Name: "rule_effective_from", Type: proto.ColumnType_TIMESTAMP, Description: "Rule effective from.", Transform: transform.FromField("RuleInfo.EffectiveFrom").Transform(ToUTCTimestamp)},
 			{Name: "rule_level_name", Type: proto.ColumnType_STRING, Description: "Rule level name.", Transform: transform.FromField("RuleInfo.LevelName")},
 			{Name: "rule_level_number", Type: proto.ColumnType_INT, Description: "Rule level number.", Transform: transform.FromField("RuleInfo.LevelNumber")},
 			{Name: "rule_weight", Type: proto.ColumnType_INT, Description: "Rule weight.", Transform: transform.FromField("RuleInfo.Weight")},
 			{Name: "rule_score", Type: proto.ColumnType_INT, Description: "Rule score.", Transform: transform.FromField("RuleScore.Score")},
 			{Name: "rule_pass", Type: proto.ColumnType_BOOL, Description: "Rule pass.", Transform: transform.FromP(transform.MethodValue, "IsRulePass")},
+			{Name: "rule_cql", Type: proto.ColumnType_STRING, Description: "CQL of the rule as defined in the scorecard.", Transform: transform.FromField("RuleInfo.Expression")},
+			{Name: "rule_filter_query", Type: proto.ColumnType_STRING, Description: "CQL limiting which entities the rule applies to.", Transform: transform.FromField("RuleInfo.Filter.Query")},
+			{Name: "scorecard_filter", Type: proto.ColumnType_JSON, Description: "Filter of the entities the scorecard applies to, by query, types and groups.", Transform: transform.FromField("ScorecardFilter")},
+			{Name: "scorecard_filter_query", Type: proto.ColumnType_STRING, Description: "CQL limiting which entities the scorecard applies to.", Transform: transform.FromField("ScorecardFilter.Query")},
 		},
 	}
 }
 
 func listScorecardScoresHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	config := GetConfig(d.Connection)
+	config := GetTableConfig(d)
 	client := CortexHTTPClient(ctx, config)
 	writer := QueryDataWriter{d}
 	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
-	logger.Info("listScorecardScoresHydrator", "scorecardTag", scorecardTag)
-	return nil, listScorecardScores(ctx, client, &writer, scorecardTag)
+	entityTag := ""
+	if d.EqualsQuals["service_tag"] != nil {
+		entityTag = d.EqualsQuals["service_tag"].GetStringValue()
+	}
+	logger.Info("listScorecardScoresHydrator", "scorecardTag", scorecardTag, "entityTag", entityTag)
+	return nil, listScorecardScores(ctx, client, &writer, scorecardTag, entityTag)
 }
 
-func listScorecardScores(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string) error {
-	logger := plugin.Logger(ctx)
-
+func listScorecardScores(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string, entityTag string) error {
 	// Get information about the scorecard to enrich the data
-	var scorecardResponse CortexScorecardResponse
-	resp := client.
-		Get("/api/v1/scorecards/{tag}").
-		SetPathParam("tag", scorecardTag).
-		Do(ctx)
-
-	// Check for HTTP errors
-	if resp.IsErrorState() {
-		logger.Error("listScorecardScores getScorecard", "Status", resp.Status, "Body", resp.String())
-		return fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
-	}
-	err := resp.Into(&scorecardResponse)
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
 	if err != nil {
-		logger.Error("listScorecardScores getScorecard", "Error", err)
 		return err
 	}
 	// Make a map of rule identifier to CortexRule
 	rules := make(map[string]*CortexRuleInfo)
-	for _, rule := range scorecardResponse.Scorecard.Rules {
+	for _, rule := range scorecard.Rules {
 		rules[rule.Identifier] = rule
 		// add level number to the rule
-		for _, level := range scorecardResponse.Scorecard.Levels {
+		for _, level := range scorecard.Levels {
 			if level.Level.Name == rule.LevelName {
 				rule.LevelNumber = level.Level.Number
 			}
@@ -156,56 +175,64 @@ func listScorecardScores(ctx context.Context, client *req.Client, writer Hydrato
 	}
 
 	// Get the scores for the scorecard
-	var response CortexScorecardScoreResponse
-	var page int = 0
-	for {
-		resp := client.
+	return getScorecardScores(ctx, client, scorecardTag, entityTag, func(result *CortexServiceScore) (bool, error) {
+		for _, ruleScore := range result.Score.Rules {
+			// Get the rule info
+			ruleInfo, ok := rules[ruleScore.Identifier]
+			if !ok {
+				continue
+			}
+			row := CortexScorecardScoreRow{
+				ScorecardName:   scorecard.Name,
+				ScorecardTag:    scorecardTag,
+				ScorecardFilter: scorecard.Filter,
+				LastEvaluated:   result.LastEvaluated,
+				Service:         result.Service,
+				RuleScore:       ruleScore,
+				RuleInfo:        ruleInfo,
+			}
+			// send the item to steampipe
+			writer.StreamListItem(ctx, row)
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if writer.RowsRemaining(ctx) == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// Pass each score of an entity in the scorecard to handle, entityTag optionally limits it to one entity.
+// Score pages can be large, scores are streamed from the body as they are decoded.
+func getScorecardScores(ctx context.Context, client *req.Client, scorecardTag string, entityTag string, handle func(score *CortexServiceScore) (bool, error)) error {
+	request := func() *req.Request {
+		return client.
 			Get("/api/v1/scorecards/{tag}/scores").
 			SetPathParam("tag", scorecardTag).
-			// Pagination
-			SetQueryParam("pageSize", "1000").
-			SetQueryParam("page", strconv.Itoa(page)).
-			Do(ctx)
-
-		// Check for HTTP errors
-		if resp.IsErrorState() {
-			logger.Error("listScorecardScores getScores", "Status", resp.Status, "Body", resp.String())
-			return fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
-		}
-		// Unmarshal the response and check for unmarshal errors
-		err := resp.Into(&response)
-		if err != nil {
-			logger.Error("listScorecardScores getScores", "page", page, "Error", err)
-			return err
-		}
+			// Filters
+			SetQueryParam("entityTag", entityTag)
+	}
+	return cortexapi.PaginateItems(ctx, request, "serviceScores", handle)
+}
 
-		for _, result := range response.ServiceScores {
-			for _, ruleScore := range result.Score.Rules {
-				// Get the rule info
-				ruleInfo, ok := rules[ruleScore.Identifier]
-				if !ok {
-					continue
-				}
-				row := CortexScorecardScoreRow{
-					ScorecardName: response.ScorecardName,
-					ScorecardTag:  response.ScorecardTag,
-					LastEvaluated: result.LastEvaluated,
-					Service:       result.Service,
-					RuleScore:     ruleScore,
-					RuleInfo:      ruleInfo,
-				}
-				// send the item to steampipe
-				writer.StreamListItem(ctx, row)
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if writer.RowsRemaining(ctx) == 0 {
-					return nil
-				}
-			}
-		}
-		page++
-		if page >= response.TotalPages {
-			break
-		}
+func getScorecard(ctx context.Context, client *req.Client, scorecardTag string) (*CortexScorecard, error) {
+	logger := plugin.Logger(ctx)
+
+	var scorecardResponse CortexScorecardResponse
+	resp := client.
+		Get("/api/v1/scorecards/{tag}").
+		SetPathParam("tag", scorecardTag).
+		Do(ctx)
+
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getScorecard", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+	err := resp.Into(&scorecardResponse)
+	if err != nil {
+		logger.Error("getScorecard", "Error", err)
+		return nil, err
 	}
-	return nil
+	return &scorecardResponse.Scorecard, nil
 }
@@ -50,9 +50,11 @@ func TestTableCortexScorecardScore(t *testing.T) {
 	// Check list configuration.
 	g.Expect(table.List).ToNot(BeNil())
 	g.Expect(table.List.Hydrate).ToNot(BeNil())
-	g.Expect(table.List.KeyColumns).To(HaveLen(1))
+	g.Expect(table.List.KeyColumns).To(HaveLen(2))
 	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
 	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("service_tag"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
 
 	// Define expected columns.
 	expectedColumns := []struct {
@@ -64,17 +66,21 @@ func TestTableCortexScorecardScore(t *testing.T) {
 		{"service_tag", proto.ColumnType_STRING},
 		{"service_name", proto.ColumnType_STRING},
 		{"service_groups", proto.ColumnType_JSON},
-		{"last_evaluated", proto.ColumnType_STRING},
+		{"last_evaluated", proto.ColumnType_TIMESTAMP},
 		{"rule_identifier", proto.ColumnType_STRING},
 		{"rule_title", proto.ColumnType_STRING},
 		{"rule_description", proto.ColumnType_STRING},
 		{"rule_expression", proto.ColumnType_STRING},
-		{"rule_effective_from", proto.ColumnType_STRING},
+		{"rule_effective_from", proto.ColumnType_TIMESTAMP},
 		{"rule_level_name", proto.ColumnType_STRING},
 		{"rule_level_number", proto.ColumnType_INT},
 		{"rule_weight", proto.ColumnType_INT},
 		{"rule_score", proto.ColumnType_INT},
 		{"rule_pass", proto.ColumnType_BOOL},
+		{"rule_cql", proto.ColumnType_STRING},
+		{"rule_filter_query", proto.ColumnType_STRING},
+		{"scorecard_filter", proto.ColumnType_JSON},
+		{"scorecard_filter_query", proto.ColumnType_STRING},
 	}
 
 	// Check that the table has the expected columns.
@@ -90,8 +96,9 @@ func TestListScorecardScoresSinglePage(t *testing.T) {
 	gh := ghttp.NewGHTTPWithGomega(g)
 
 	scorecard := CortexScorecard{
+		Filter: CortexScorecardFilter{Query: "entity_descriptor.type = \"service\"", Types: CortexFilterInclusion{Include: []string{"service"}}},
 		Rules: []*CortexRuleInfo{
-			{Identifier: "rule1", Title: "Rule 1", LevelName: "Level 1", Weight: 10},
+			{Identifier: "rule1", Title: "Rule 1", LevelName: "Level 1", Weight: 10, Expression: "git != null", Filter: CortexScorecardFilter{Query: "hasGroup(\"prod\")"}},
 		},
 		Levels: []*CortexScorecardLevel{
 			{Level: CortexLevel{Name: "Level 1", Number: 1}},
@@ -126,13 +133,54 @@ func TestListScorecardScoresSinglePage(t *testing.T) {
 
 	writer := NewSliceWriter[CortexScorecardScoreRow](100)
 
-	err := listScorecardScores(ctx, client, writer, "tag1")
+	err := listScorecardScores(ctx, client, writer, "tag1", "")
 	g.Expect(err).To(BeNil())
 
 	g.Expect(writer.Items).To(HaveLen(1))
 	g.Expect(writer.Items[0].Service.Name).To(Equal("Service 1"))
 	g.Expect(writer.Items[0].RuleScore.Identifier).To(Equal("rule1"))
 	g.Expect(writer.Items[0].RuleScore.Score).To(Equal(10))
+	g.Expect(writer.Items[0].RuleInfo.Expression).To(Equal("git != null"))
+	g.Expect(writer.Items[0].RuleInfo.Filter.Query).To(Equal("hasGroup(\"prod\")"))
+	g.Expect(writer.Items[0].ScorecardFilter.Query).To(Equal("entity_descriptor.type = \"service\""))
+	g.Expect(writer.Items[0].ScorecardFilter.Types.Include).To(Equal([]string{"service"}))
+}
+
+func TestListScorecardScoresForEntity(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	scorecard := CortexScorecard{
+		Rules: []*CortexRuleInfo{{Identifier: "rule1", Weight: 1}},
+	}
+	scores := []*CortexServiceScore{
+		{
+			Service: &CortexEntityElement{Tag: "service1"},
+			Score:   &CortexScore{Rules: []*CortexRuleScore{{Identifier: "rule1", Score: 0}}},
+		},
+	}
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.VerifyFormKV("entityTag", "service1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores, 0, 1, 1), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexScorecardScoreRow](100)
+
+	err := listScorecardScores(ctx, client, writer, "tag1", "service1")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Service.Tag).To(Equal("service1"))
+	g.Expect(writer.Items[0].IsRulePass()).To(BeFalse())
 }
 
 func TestListScorecardScoresError(t *testing.T) {
@@ -149,7 +197,7 @@ func TestListScorecardScoresError(t *testing.T) {
 
 	writer := NewSliceWriter[CortexScorecardScoreRow](100)
 
-	err := listScorecardScores(ctx, client, writer, "tag1")
+	err := listScorecardScores(ctx, client, writer, "tag1", "")
 	g.Expect(err).ToNot(BeNil())
 	g.Expect(err.Error()).To(Equal("error from cortex API 500 Internal Server Error: {\"details\": \"fake error on scorecard\"}"))
 }
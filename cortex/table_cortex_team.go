@@ -2,9 +2,12 @@ package cortex
 
 import (
 	"context"
-	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -15,16 +18,57 @@ type CortexTeamResponse struct {
 }
 
 type CortexTeamElement struct {
-	Tag      string                 `yaml:"teamTag"`
-	Metadata map[string]interface{} `yaml:"metadata"`
-	Links    []CortexLink           `yaml:"links"`
-	Archived bool                   `yaml:"isArchived"`
-	Slack    []CortexSlackChannel   `yaml:"slackChannels"`
-	IDPGroup CortexTeamIDPGroup     `yaml:"idpGroup"`
+	ID               string                 `yaml:"id"`
+	Tag              string                 `yaml:"teamTag"`
+	CatalogEntityTag string                 `yaml:"catalogEntityTag"`
+	TeamType         string                 `yaml:"teamType"`
+	Metadata         map[string]interface{} `yaml:"metadata"`
+	Links            []CortexLink           `yaml:"links"`
+	Archived         bool                   `yaml:"isArchived"`
+	Slack            []CortexSlackChannel   `yaml:"slackChannels"`
+	IDPGroup         CortexTeamIDPGroup     `yaml:"idpGroup"`
+	CortexTeam       CortexTeam             `yaml:"cortexTeam"`
 
 	// Enriched data
-	Children []string `yaml:"-"`
-	Parents  []string `yaml:"-"`
+	Children        []string `yaml:"-"`
+	Parents         []string `yaml:"-"`
+	DescendantCount int      `yaml:"-"`
+	Ancestors       []string `yaml:"-"`
+}
+
+// Members of a team from the per-team endpoint, the list endpoint only returns sparse member details
+type CortexTeamMembers struct {
+	Members []CortexTeamMember
+	Error   string
+}
+
+// Whether the team name or tag contains the text, ignoring case
+func (t CortexTeamElement) Matches(search string) bool {
+	search = strings.ToLower(search)
+	name, _ := t.Metadata["name"].(string)
+	return strings.Contains(strings.ToLower(name), search) || strings.Contains(strings.ToLower(t.Tag), search)
+}
+
+// The first parent, teams rarely have more than one
+func (t CortexTeamElement) ParentTag() string {
+	if len(t.Parents) == 0 {
+		return ""
+	}
+	return t.Parents[0]
+}
+
+// Members synced from the IdP group and those added manually in Cortex, each with where it came from.
+func (t CortexTeamElement) AllMembers() []CortexTeamMember {
+	var members []CortexTeamMember
+	for _, member := range t.IDPGroup.Members {
+		member.Source = t.IDPGroup.Provider
+		members = append(members, member)
+	}
+	for _, member := range t.CortexTeam.Members {
+		member.Source = "CORTEX"
+		members = append(members, member)
+	}
+	return members
 }
 
 type CortexTeamIDPGroup struct {
@@ -53,65 +97,101 @@ func tableCortexTeam() *plugin.Table {
 		Description: "Cortex list teams api.",
 		List: &plugin.ListConfig{
 			Hydrate: listTeamsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "include_teams_without_members", Require: plugin.Optional},
+				{Name: "source", Require: plugin.Optional},
+				{Name: "search", Require: plugin.Optional},
+				{Name: "max_depth", Require: plugin.Optional},
+			},
+		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{Func: getTeamMembersHydrator, MaxConcurrency: MaxHydrateConcurrency},
 		},
 		Columns: []*plugin.Column{
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "The pretty name of the team.", Transform: transform.FromField("Metadata.name")},
 			{Name: "tag", Type: proto.ColumnType_STRING, Description: "The teamTag of the team."},
+			{Name: "id", Type: proto.ColumnType_STRING, Description: "Cortex ID of the team.", Transform: transform.FromField("ID")},
+			{Name: "catalog_entity_tag", Type: proto.ColumnType_STRING, Description: "Tag of the catalog entity for the team, to join with cortex_entity.", Transform: transform.FromField("CatalogEntityTag")},
 			{Name: "parents", Type: proto.ColumnType_JSON, Description: "Parents of the entity."},
-			{Name: "children", Type: proto.ColumnType_JSON, Description: "Parents of the entity."},
+			{Name: "parent_tag", Type: proto.ColumnType_STRING, Description: "Tag of the first parent team, null for root teams.", Transform: transform.FromP(transform.MethodValue, "ParentTag").NullIfZero()},
+			{Name: "children", Type: proto.ColumnType_JSON, Description: "Child teams, down to max_depth levels below the team."},
+			{Name: "ancestors", Type: proto.ColumnType_JSON, Description: "All teams above this team in the hierarchy, from the root down to the parent."},
+			{Name: "descendant_count", Type: proto.ColumnType_INT, Description: "Number of teams below this team in the hierarchy.", Transform: transform.FromField("DescendantCount")},
 			{Name: "metadata", Type: proto.ColumnType_JSON, Description: "Raw custom metadata"},
 			{Name: "links", Type: proto.ColumnType_JSON, Description: "List of links", Transform: FromStructSlice[CortexLink]("Links", "Url")},
 			{Name: "archived", Type: proto.ColumnType_BOOL, Description: "Is archived."},
 			{Name: "slack_channels", Type: proto.ColumnType_JSON, Description: "List of string slack channels"},
-			{Name: "members", Type: proto.ColumnType_JSON, Description: "List of members", Transform: transform.FromField("IDPGroup.Members")},
+			{Name: "slack_notifications_enabled", Type: proto.ColumnType_BOOL, Description: "True if any slack channel has notifications enabled.", Transform: transform.FromField("Slack").Transform(AnySlackNotificationsEnabled)},
+			{Name: "members", Type: proto.ColumnType_JSON, Description: "List of members with their role and source", Hydrate: getTeamMembersHydrator, Transform: transform.FromField("Members")},
+			{Name: "member_emails", Type: proto.ColumnType_JSON, Description: "List of member emails", Hydrate: getTeamMembersHydrator, Transform: FromStructSlice[CortexTeamMember]("Members", "Email")},
+			{Name: "source", Type: proto.ColumnType_STRING, Description: "Identity provider the team is synced from, e.g. OKTA, null for teams managed in Cortex. Filtering on source is done by the plugin, not the API.", Transform: transform.FromField("IDPGroup.Provider").NullIfZero()},
+			{Name: "team_type", Type: proto.ColumnType_STRING, Description: "Type of the team as returned by Cortex.", Transform: transform.FromField("TeamType")},
+			{Name: "include_teams_without_members", Type: proto.ColumnType_BOOL, Description: "Whether teams without members were requested, defaults to true.", Transform: transform.FromQual("include_teams_without_members").Transform(NullToTrue)},
+			{Name: "search", Type: proto.ColumnType_STRING, Description: "Text the team name or tag must contain, ignoring case.", Transform: transform.FromQual("search")},
+			{Name: "max_depth", Type: proto.ColumnType_INT, Description: "How many levels below the team are listed in children, defaults to 1.", Transform: transform.FromQual("max_depth")},
+			{Name: "error", Type: proto.ColumnType_STRING, Description: "Error fetching members, only set when ignore_row_errors is enabled.", Hydrate: getTeamMembersHydrator, Transform: transform.FromField("Error").NullIfZero()},
 		},
 	}
 }
 
 func listTeamsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	config := GetConfig(d.Connection)
+	config := GetTableConfig(d)
 	client := CortexHTTPClient(ctx, config)
 	hydratorWriter := QueryDataWriter{d}
 	relationships, err := getTeamRelationships(ctx, client)
 	if err != nil {
 		logger.Warn("listTeams", "Error", err)
 	}
-	logger.Info("listTeamsHydrator", "Starting hydrator")
-	return nil, listTeams(ctx, client, &hydratorWriter, relationships)
-}
 
-func listTeams(ctx context.Context, client *req.Client, writer HydratorWriter, relationships map[string]Relationships) error {
-	logger := plugin.Logger(ctx)
+	// Extract parameters from QueryData
+	includeTeamsWithoutMembers := "true"
+	if d.EqualsQuals["include_teams_without_members"] != nil && !d.EqualsQuals["include_teams_without_members"].GetBoolValue() {
+		includeTeamsWithoutMembers = "false"
+	}
+	source := ""
+	if d.EqualsQuals["source"] != nil {
+		source = d.EqualsQuals["source"].GetStringValue()
+	}
+	search := ""
+	if d.EqualsQuals["search"] != nil {
+		search = d.EqualsQuals["search"].GetStringValue()
+	}
+	maxDepth := 1
+	if d.EqualsQuals["max_depth"] != nil {
+		maxDepth = int(d.EqualsQuals["max_depth"].GetInt64Value())
+	}
 
-	resp := client.
-		Get("/api/v1/teams").
-		SetQueryParam("includeTeamsWithoutMembers", "true").
-		Do(ctx)
+	logger.Info("listTeamsHydrator", "includeTeamsWithoutMembers", includeTeamsWithoutMembers, "source", source, "search", search, "maxDepth", maxDepth)
+	return nil, listTeams(ctx, client, &hydratorWriter, relationships, includeTeamsWithoutMembers, source, search, maxDepth)
+}
 
-		// Check for HTTP errors
-	if resp.IsErrorState() {
-		logger.Error("listTeams", "Status", resp.Status, "Body", resp.String())
-		return fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
-	}
+func listTeams(ctx context.Context, client *req.Client, writer HydratorWriter, relationships map[string]Relationships, includeTeamsWithoutMembers string, source string, search string, maxDepth int) error {
+	logger := plugin.Logger(ctx)
 
-	// Unmarshal the response and check for unmarshal errors
-	var response CortexTeamResponse
-	err := resp.Into(&response)
+	teams, err := getTeams(ctx, client, includeTeamsWithoutMembers)
 	if err != nil {
-		logger.Error("listTeams", "Error", err)
 		return err
 	}
-	logger.Info("listTeams", "results", len(response.Teams))
 
-	for _, result := range response.Teams {
+	for _, result := range teams {
+		// The teams API has no source filter so skip non-matching teams here
+		if source != "" && result.IDPGroup.Provider != source {
+			continue
+		}
+		// The teams API has no search either, match on the name or tag
+		if search != "" && !result.Matches(search) {
+			continue
+		}
 		// enrich the data
-		relationships, ok := relationships[result.Tag]
-		logger.Debug("listTeams", "relationships", relationships, "ok", ok)
+		teamRelationships, ok := relationships[result.Tag]
+		logger.Debug("listTeams", "relationships", teamRelationships, "ok", ok)
 		if ok {
-			result.Children = relationships.Children
-			result.Parents = relationships.Parents
+			result.Children = teamChildren(result.Tag, relationships, maxDepth)
+			result.Parents = teamRelationships.Parents
 		}
+		result.DescendantCount = countDescendants(result.Tag, relationships)
+		result.Ancestors = teamAncestors(result.Tag, relationships)
 		// send the item to steampipe
 		writer.StreamListItem(ctx, result)
 		// Context can be cancelled due to manual cancellation or the limit has been hit
@@ -122,6 +202,83 @@ func listTeams(ctx context.Context, client *req.Client, writer HydratorWriter, r
 	return nil
 }
 
+func getTeams(ctx context.Context, client *req.Client, includeTeamsWithoutMembers string) ([]CortexTeamElement, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/teams").
+		SetQueryParam("includeTeamsWithoutMembers", includeTeamsWithoutMembers).
+		Do(ctx)
+
+		// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getTeams", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var response CortexTeamResponse
+	err := resp.Into(&response)
+	if err != nil {
+		logger.Error("getTeams", "Error", err)
+		return nil, err
+	}
+	logger.Info("getTeams", "results", len(response.Teams))
+	return response.Teams, nil
+}
+
+func getTeamMembersHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	team := h.Item.(CortexTeamElement)
+	members, err := getTeamMembers(ctx, client, team)
+	if err != nil {
+		message, err := rowError(ctx, d, err)
+		return CortexTeamMembers{Error: message}, err
+	}
+	return members, nil
+}
+
+// Full member details of the team, falls back to the listed members if the team is not found
+func getTeamMembers(ctx context.Context, client *req.Client, team CortexTeamElement) (CortexTeamMembers, error) {
+	fullTeam, err := getTeam(ctx, client, team.Tag)
+	if err != nil {
+		return CortexTeamMembers{}, err
+	}
+	if fullTeam == nil {
+		return CortexTeamMembers{Members: team.AllMembers()}, nil
+	}
+	return CortexTeamMembers{Members: fullTeam.AllMembers()}, nil
+}
+
+// Get a single team, nil if there is no team with the tag
+func getTeam(ctx context.Context, client *req.Client, tag string) (*CortexTeamElement, error) {
+	logger := plugin.Logger(ctx)
+
+	resp := client.
+		Get("/api/v1/teams/{tag}").
+		SetPathParam("tag", tag).
+		Do(ctx)
+
+	if resp.GetStatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	// Check for HTTP errors
+	if resp.IsErrorState() {
+		logger.Error("getTeam", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
+	}
+
+	// Unmarshal the response and check for unmarshal errors
+	var team CortexTeamElement
+	err := resp.Into(&team)
+	if err != nil {
+		logger.Error("getTeam", "Error", err)
+		return nil, err
+	}
+	return &team, nil
+}
+
 func getTeamRelationships(ctx context.Context, client *req.Client) (map[string]Relationships, error) {
 	logger := plugin.Logger(ctx)
 	relationships := make(map[string]Relationships)
@@ -131,8 +288,8 @@ func getTeamRelationships(ctx context.Context, client *req.Client) (map[string]R
 		Do(ctx)
 
 	if resp.IsErrorState() {
-		logger.Error("getTeamRelationships", "Status", resp.Status, "Body", resp.String())
-		return nil, fmt.Errorf("error from cortex API %s: %s", resp.Status, resp.String())
+		logger.Error("getTeamRelationships", "Status", resp.Status, "RequestID", resp.GetHeader(cortexapi.RequestIDHeader), "Body", resp.String())
+		return nil, cortexapi.NewError(resp)
 	}
 
 	var response CortexRelationshipsResponse
@@ -152,3 +309,67 @@ func getTeamRelationships(ctx context.Context, client *req.Client) (map[string]R
 	}
 	return relationships, nil
 }
+
+// Count the unique teams below the given team, following children transitively.
+// A visited set guards against teams reachable through several parents and cycles.
+func countDescendants(tag string, relationships map[string]Relationships) int {
+	visited := map[string]bool{tag: true}
+	queue := []string{tag}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range relationships[current].Children {
+			if !visited[child] {
+				visited[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return len(visited) - 1
+}
+
+// Unique teams up to maxDepth levels below the given team, nearest first. A depth below 1 lists direct children.
+func teamChildren(tag string, relationships map[string]Relationships, maxDepth int) []string {
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+	var children []string
+	visited := map[string]bool{tag: true}
+	level := []string{tag}
+	for depth := 0; len(level) > 0 && depth < maxDepth; depth++ {
+		var next []string
+		for _, current := range level {
+			for _, child := range relationships[current].Children {
+				if !visited[child] {
+					visited[child] = true
+					children = append(children, child)
+					next = append(next, child)
+				}
+			}
+		}
+		level = next
+	}
+	return children
+}
+
+// All unique teams above the given team, from the root down to the nearest parents.
+// Parents are followed transitively, a visited set guards against shared ancestors and cycles.
+func teamAncestors(tag string, relationships map[string]Relationships) []string {
+	var ancestors []string
+	visited := map[string]bool{tag: true}
+	queue := []string{tag}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parent := range relationships[current].Parents {
+			if !visited[parent] {
+				visited[parent] = true
+				ancestors = append(ancestors, parent)
+				queue = append(queue, parent)
+			}
+		}
+	}
+	// Found nearest first, reverse to start at the root
+	slices.Reverse(ancestors)
+	return ancestors
+}
@@ -0,0 +1,128 @@
+package cortex
+
+import (
+	"context"
+	"slices"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexTeamHierarchyRow struct {
+	Tag       string
+	ParentTag string
+	Depth     int
+	Path      []string
+	InCycle   bool
+}
+
+func tableCortexTeamHierarchy() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_team_hierarchy",
+		Description: "Cortex team hierarchy from the team relationships api.",
+		List: &plugin.ListConfig{
+			Hydrate: listTeamHierarchyHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "tag", Type: proto.ColumnType_STRING, Description: "The teamTag of the team."},
+			{Name: "parent_tag", Type: proto.ColumnType_STRING, Description: "The teamTag of the parent team, null for root teams and where a rootless cycle is walked from."},
+			{Name: "depth", Type: proto.ColumnType_INT, Description: "Depth of the team in the hierarchy, 0 for root teams and where a rootless cycle is walked from.", Transform: transform.FromField("Depth")},
+			{Name: "path", Type: proto.ColumnType_JSON, Description: "List of team tags from the root team down to this team."},
+			{Name: "in_cycle", Type: proto.ColumnType_BOOL, Description: "Whether the team is only reachable through a cycle without a root team, the path then starts from the first team of the cycle by tag.", Transform: transform.FromField("InCycle")},
+		},
+	}
+}
+
+func listTeamHierarchyHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listTeamHierarchy(ctx, client, &hydratorWriter)
+}
+
+func listTeamHierarchy(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	logger := plugin.Logger(ctx)
+
+	relationships, err := getTeamRelationships(ctx, client)
+	if err != nil {
+		return err
+	}
+	// Teams without any relationships are still roots of their own tree
+	teams, err := getTeams(ctx, client, "true")
+	if err != nil {
+		return err
+	}
+	tags := make([]string, 0, len(teams))
+	for _, team := range teams {
+		tags = append(tags, team.Tag)
+	}
+
+	rows := buildTeamHierarchy(tags, relationships)
+	logger.Info("listTeamHierarchy", "results", len(rows))
+	for _, row := range rows {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, row)
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Walk the hierarchy from each root team and return one row for every path to a team.
+// A team with several parents will therefore appear once per parent.
+// Teams in a cycle without a root team are walked from the first team of the cycle instead.
+func buildTeamHierarchy(tags []string, relationships map[string]Relationships) []CortexTeamHierarchyRow {
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		seen[tag] = true
+	}
+	for tag := range relationships {
+		seen[tag] = true
+	}
+	var allTags, roots []string
+	for tag := range seen {
+		allTags = append(allTags, tag)
+		if len(relationships[tag].Parents) == 0 {
+			roots = append(roots, tag)
+		}
+	}
+	slices.Sort(allTags)
+	slices.Sort(roots)
+
+	var rows []CortexTeamHierarchyRow
+	visited := map[string]bool{}
+	var walk func(tag string, parent string, path []string, inCycle bool)
+	walk = func(tag string, parent string, path []string, inCycle bool) {
+		// Guard against cycles in the relationships
+		if slices.Contains(path, tag) {
+			return
+		}
+		visited[tag] = true
+		path = append(slices.Clone(path), tag)
+		rows = append(rows, CortexTeamHierarchyRow{
+			Tag:       tag,
+			ParentTag: parent,
+			Depth:     len(path) - 1,
+			Path:      path,
+			InCycle:   inCycle,
+		})
+		for _, child := range relationships[tag].Children {
+			walk(child, tag, path, inCycle)
+		}
+	}
+	for _, root := range roots {
+		walk(root, "", nil, false)
+	}
+	// Anything not reached from a root is part of, or below, a cycle
+	for _, tag := range allTags {
+		if !visited[tag] {
+			walk(tag, "", nil, true)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,169 @@
+package cortex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+func TestTableCortexTeamHierarchy(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexTeamHierarchy()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_team_hierarchy"))
+	g.Expect(table.Description).To(Equal("Cortex team hierarchy from the team relationships api."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"tag", proto.ColumnType_STRING},
+		{"parent_tag", proto.ColumnType_STRING},
+		{"depth", proto.ColumnType_INT},
+		{"path", proto.ColumnType_JSON},
+		{"in_cycle", proto.ColumnType_BOOL},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestBuildTeamHierarchy(t *testing.T) {
+	g := NewWithT(t)
+
+	relationships := map[string]Relationships{
+		"root":       {Children: []string{"child1", "child2"}},
+		"child1":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"child2":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"grandchild": {Parents: []string{"child1", "child2"}},
+	}
+
+	rows := buildTeamHierarchy([]string{"root", "child1", "child2", "grandchild", "lonely"}, relationships)
+
+	g.Expect(rows).To(Equal([]CortexTeamHierarchyRow{
+		{Tag: "lonely", ParentTag: "", Depth: 0, Path: []string{"lonely"}},
+		{Tag: "root", ParentTag: "", Depth: 0, Path: []string{"root"}},
+		{Tag: "child1", ParentTag: "root", Depth: 1, Path: []string{"root", "child1"}},
+		{Tag: "grandchild", ParentTag: "child1", Depth: 2, Path: []string{"root", "child1", "grandchild"}},
+		{Tag: "child2", ParentTag: "root", Depth: 1, Path: []string{"root", "child2"}},
+		{Tag: "grandchild", ParentTag: "child2", Depth: 2, Path: []string{"root", "child2", "grandchild"}},
+	}))
+}
+
+func TestBuildTeamHierarchyCycle(t *testing.T) {
+	g := NewWithT(t)
+
+	relationships := map[string]Relationships{
+		"root":   {Children: []string{"child1"}},
+		"child1": {Parents: []string{"root", "child2"}, Children: []string{"child2"}},
+		"child2": {Parents: []string{"child1"}, Children: []string{"child1"}},
+	}
+
+	rows := buildTeamHierarchy(nil, relationships)
+
+	g.Expect(rows).To(HaveLen(3))
+	g.Expect(rows[2].Path).To(Equal([]string{"root", "child1", "child2"}))
+}
+
+func TestBuildTeamHierarchyRootlessCycle(t *testing.T) {
+	g := NewWithT(t)
+
+	relationships := map[string]Relationships{
+		"root":   {},
+		"team-b": {Parents: []string{"team-a"}, Children: []string{"team-a", "team-c"}},
+		"team-a": {Parents: []string{"team-b"}, Children: []string{"team-b"}},
+		"team-c": {Parents: []string{"team-b"}},
+	}
+
+	rows := buildTeamHierarchy([]string{"root", "team-a", "team-b", "team-c"}, relationships)
+
+	g.Expect(rows).To(Equal([]CortexTeamHierarchyRow{
+		{Tag: "root", ParentTag: "", Depth: 0, Path: []string{"root"}},
+		{Tag: "team-a", ParentTag: "", Depth: 0, Path: []string{"team-a"}, InCycle: true},
+		{Tag: "team-b", ParentTag: "team-a", Depth: 1, Path: []string{"team-a", "team-b"}, InCycle: true},
+		{Tag: "team-c", ParentTag: "team-b", Depth: 2, Path: []string{"team-a", "team-b", "team-c"}, InCycle: true},
+	}))
+}
+
+func TestTeamHierarchyInCycle(t *testing.T) {
+	g := NewWithT(t)
+	column := getColumn(tableCortexTeamHierarchy(), "in_cycle")
+
+	// Teams outside a cycle are false rather than null, so `where not in_cycle` finds them
+	for _, inCycle := range []bool{true, false} {
+		value, err := column.Transform.Execute(context.Background(), &transform.TransformData{HydrateItem: CortexTeamHierarchyRow{Tag: "team-a", InCycle: inCycle}, ColumnName: "in_cycle"})
+		g.Expect(err).To(BeNil())
+		g.Expect(value).To(Equal(inCycle))
+	}
+}
+
+func TestListTeamHierarchy(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	relationshipsBytes := prepareRelationshipsResponse(t, []CortexRelationshipsEdge{
+		{Child: "child1", Parent: "parent1"},
+	})
+	teamsBytes := prepareTeamResponse(t, []CortexTeamElement{{Tag: "parent1"}, {Tag: "child1"}})
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, relationshipsBytes, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, teamsBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamHierarchyRow](100)
+
+	err := listTeamHierarchy(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].Tag).To(Equal("parent1"))
+	g.Expect(writer.Items[1].Tag).To(Equal("child1"))
+	g.Expect(writer.Items[1].ParentTag).To(Equal("parent1"))
+	g.Expect(writer.Items[1].Path).To(Equal([]string{"parent1", "child1"}))
+}
+
+func TestListTeamHierarchyError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/relationships"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusInternalServerError, "{\"details\": \"fake error on relationships\"}", nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamHierarchyRow](100)
+
+	err := listTeamHierarchy(ctx, client, writer)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 500 Internal Server Error: {\"details\": \"fake error on relationships\"}"))
+}
@@ -0,0 +1,66 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexTeamLinkRow struct {
+	TeamTag  string
+	TeamName string
+	Link     CortexLink
+}
+
+func tableCortexTeamLink() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_team_link",
+		Description: "Cortex links of each team.",
+		List: &plugin.ListConfig{
+			Hydrate: listTeamLinksHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "team_tag", Type: proto.ColumnType_STRING, Description: "The teamTag of the team."},
+			{Name: "team_name", Type: proto.ColumnType_STRING, Description: "The pretty name of the team."},
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the link.", Transform: transform.FromField("Link.Name")},
+			{Name: "type", Type: proto.ColumnType_STRING, Description: "Type of the link, e.g. runbook or dashboard.", Transform: transform.FromField("Link.Type")},
+			{Name: "url", Type: proto.ColumnType_STRING, Description: "Link URL.", Transform: transform.FromField("Link.Url")},
+		},
+	}
+}
+
+func listTeamLinksHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	hydratorWriter := QueryDataWriter{d}
+	return nil, listTeamLinks(ctx, client, &hydratorWriter)
+}
+
+func listTeamLinks(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	teams, err := getTeams(ctx, client, "true")
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		name, _ := team.Metadata["name"].(string)
+		for _, link := range team.Links {
+			row := CortexTeamLinkRow{
+				TeamTag:  team.Tag,
+				TeamName: name,
+				Link:     link,
+			}
+			// send the item to steampipe
+			writer.StreamListItem(ctx, row)
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if writer.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+	return nil
+}
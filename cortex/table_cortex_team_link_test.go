@@ -0,0 +1,75 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+)
+
+func TestTableCortexTeamLink(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexTeamLink()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_team_link"))
+	g.Expect(table.Description).To(Equal("Cortex links of each team."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"team_tag", proto.ColumnType_STRING},
+		{"team_name", proto.ColumnType_STRING},
+		{"name", proto.ColumnType_STRING},
+		{"type", proto.ColumnType_STRING},
+		{"url", proto.ColumnType_STRING},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListTeamLinks(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	teamsBytes := prepareTeamResponse(t, []CortexTeamElement{
+		{Tag: "team1", Metadata: map[string]interface{}{"name": "Team 1"}, Links: []CortexLink{
+			{Name: "Runbook", Type: "runbook", Url: "https://runbooks.example.com/team1"},
+			{Name: "Board", Type: "dashboard", Url: "https://boards.example.com/team1"},
+		}},
+		{Tag: "team2"},
+	})
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams"),
+			gh.VerifyFormKV("includeTeamsWithoutMembers", "true"),
+			gh.RespondWith(http.StatusOK, teamsBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamLinkRow](100)
+
+	err := listTeamLinks(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].TeamTag).To(Equal("team1"))
+	g.Expect(writer.Items[0].TeamName).To(Equal("Team 1"))
+	g.Expect(writer.Items[1].Link.Type).To(Equal("dashboard"))
+}
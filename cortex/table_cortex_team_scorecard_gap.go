@@ -0,0 +1,143 @@
+package cortex
+
+import (
+	"context"
+	"sort"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexTeamScorecardGapRow struct {
+	TeamTag            string
+	ScorecardTag       string
+	ScorecardName      string
+	Level              CortexLevel
+	FailingEntityCount int
+	FailingEntityTags  []string
+}
+
+func tableCortexTeamScorecardGap() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_team_scorecard_gap",
+		Description: "Teams owning entities that fail a level of a Cortex scorecard.",
+		List: &plugin.ListConfig{
+			Hydrate: listTeamScorecardGapsHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "scorecard_tag", Require: plugin.Required},
+				{Name: "level_name", Require: plugin.Optional},
+				{Name: "team_tag", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "team_tag", Type: proto.ColumnType_STRING, Description: "Tag of the owning team."},
+			{Name: "scorecard_tag", Type: proto.ColumnType_STRING, Description: "Scorecard tag."},
+			{Name: "scorecard_name", Type: proto.ColumnType_STRING, Description: "Scorecard name."},
+			{Name: "level_name", Type: proto.ColumnType_STRING, Description: "Level name.", Transform: transform.FromField("Level.Name")},
+			{Name: "level_rank", Type: proto.ColumnType_INT, Description: "Level number, 1 is the first level of the ladder.", Transform: transform.FromField("Level.Number")},
+			{Name: "failing_entity_count", Type: proto.ColumnType_INT, Description: "Number of the team's entities failing a rule of the level.", Transform: transform.FromField("FailingEntityCount")},
+			{Name: "failing_entity_tags", Type: proto.ColumnType_JSON, Description: "Tags of the team's entities failing a rule of the level.", Transform: transform.FromField("FailingEntityTags")},
+		},
+	}
+}
+
+func listTeamScorecardGapsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
+	levelName := ""
+	if d.EqualsQuals["level_name"] != nil {
+		levelName = d.EqualsQuals["level_name"].GetStringValue()
+	}
+	teamTag := ""
+	if d.EqualsQuals["team_tag"] != nil {
+		teamTag = d.EqualsQuals["team_tag"].GetStringValue()
+	}
+	logger.Info("listTeamScorecardGapsHydrator", "scorecardTag", scorecardTag, "levelName", levelName, "teamTag", teamTag)
+	return nil, listTeamScorecardGaps(ctx, client, &writer, scorecardTag, levelName, teamTag)
+}
+
+// Stream a row for each team and level with failing entities, ordered by team and level
+func listTeamScorecardGaps(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string, levelName string, teamTag string) error {
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
+	if err != nil {
+		return err
+	}
+	rules := make(map[string]*CortexRuleInfo)
+	for _, rule := range scorecard.Rules {
+		rules[rule.Identifier] = rule
+	}
+	levels := make([]CortexLevel, 0, len(scorecard.Levels))
+	for _, level := range scorecard.Levels {
+		if levelName == "" || level.Level.Name == levelName {
+			levels = append(levels, level.Level)
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Number < levels[j].Number })
+
+	// Scores only name the entity, the catalog has its owners
+	owners := EntityOwnerWriter{OwnerTeams: make(map[string][]string)}
+	err = listEntities(ctx, client, &owners, "false", "", "", EntityIncludes{Owners: true})
+	if err != nil {
+		return err
+	}
+
+	// Failing entity tags by team, then level name
+	gaps := make(map[string]map[string][]string)
+	err = getScorecardScores(ctx, client, scorecardTag, "", func(result *CortexServiceScore) (bool, error) {
+		failed := failedLevels(result.Score, rules)
+		for _, team := range owners.OwnerTeams[result.Service.Tag] {
+			if teamTag != "" && team != teamTag {
+				continue
+			}
+			for _, level := range levels {
+				if !failed[level.Name] {
+					continue
+				}
+				if gaps[team] == nil {
+					gaps[team] = make(map[string][]string)
+				}
+				gaps[team][level.Name] = append(gaps[team][level.Name], result.Service.Tag)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	teams := make([]string, 0, len(gaps))
+	for team := range gaps {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	for _, team := range teams {
+		for _, level := range levels {
+			tags, ok := gaps[team][level.Name]
+			if !ok {
+				continue
+			}
+			sort.Strings(tags)
+			row := CortexTeamScorecardGapRow{
+				TeamTag:            team,
+				ScorecardTag:       scorecardTag,
+				ScorecardName:      scorecard.Name,
+				Level:              level,
+				FailingEntityCount: len(tags),
+				FailingEntityTags:  tags,
+			}
+			// send the item to steampipe
+			writer.StreamListItem(ctx, row)
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if writer.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+	return nil
+}
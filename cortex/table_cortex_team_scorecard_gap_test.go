@@ -0,0 +1,143 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexTeamScorecardGap(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexTeamScorecardGap()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_team_scorecard_gap"))
+	g.Expect(table.Description).To(Equal("Teams owning entities that fail a level of a Cortex scorecard."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(3))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("level_name"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[2].Name).To(Equal("team_tag"))
+	g.Expect(table.List.KeyColumns[2].Require).To(Equal(plugin.Optional))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"team_tag", proto.ColumnType_STRING},
+		{"scorecard_tag", proto.ColumnType_STRING},
+		{"scorecard_name", proto.ColumnType_STRING},
+		{"level_name", proto.ColumnType_STRING},
+		{"level_rank", proto.ColumnType_INT},
+		{"failing_entity_count", proto.ColumnType_INT},
+		{"failing_entity_tags", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func prepareTeamScorecardGapHandlers(t *testing.T, gh *ghttp.GHTTPWithGomega) []http.HandlerFunc {
+	t.Helper()
+	scorecard := CortexScorecard{
+		Name: "Production Readiness",
+		Levels: []*CortexScorecardLevel{
+			{Level: CortexLevel{Name: "Silver", Number: 2}},
+			{Level: CortexLevel{Name: "Bronze", Number: 1}},
+		},
+		Rules: []*CortexRuleInfo{
+			{Identifier: "rule1", LevelName: "Bronze", Weight: 1},
+			{Identifier: "rule2", LevelName: "Silver", Weight: 3},
+		},
+	}
+	entities := []CortexEntityElement{
+		{Tag: "service1", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team-a"}}}},
+		{Tag: "service2", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team-a"}, {Tag: "team-b"}}}},
+		{Tag: "service3"},
+		{Tag: "service4", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team-b"}}}},
+	}
+	scores := []*CortexServiceScore{
+		{Service: &CortexEntityElement{Tag: "service1"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 1},
+			{Identifier: "rule2", Score: 3},
+		}}},
+		{Service: &CortexEntityElement{Tag: "service4"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 0},
+			{Identifier: "rule2", Score: 0},
+		}}},
+		{Service: &CortexEntityElement{Tag: "service2"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 1},
+			{Identifier: "rule2", Score: 0},
+		}}},
+		{Service: &CortexEntityElement{Tag: "service3"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 0},
+		}}},
+	}
+	return []http.HandlerFunc{
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusOK, prepareEntityResponse(t, entities, 0, 1, 4), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores, 0, 1, 4), nil),
+		),
+	}
+}
+
+func TestListTeamScorecardGaps(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t, prepareTeamScorecardGapHandlers(t, gh)...)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamScorecardGapRow](100)
+
+	err := listTeamScorecardGaps(ctx, client, writer, "tag1", "", "")
+	g.Expect(err).To(BeNil())
+
+	// Entities without an owning team are left out, levels are in ladder order
+	bronze, silver := CortexLevel{Name: "Bronze", Number: 1}, CortexLevel{Name: "Silver", Number: 2}
+	g.Expect(writer.Items).To(Equal([]CortexTeamScorecardGapRow{
+		{TeamTag: "team-a", ScorecardTag: "tag1", ScorecardName: "Production Readiness", Level: silver, FailingEntityCount: 1, FailingEntityTags: []string{"service2"}},
+		{TeamTag: "team-b", ScorecardTag: "tag1", ScorecardName: "Production Readiness", Level: bronze, FailingEntityCount: 1, FailingEntityTags: []string{"service4"}},
+		{TeamTag: "team-b", ScorecardTag: "tag1", ScorecardName: "Production Readiness", Level: silver, FailingEntityCount: 2, FailingEntityTags: []string{"service2", "service4"}},
+	}))
+}
+
+func TestListTeamScorecardGapsForLevelAndTeam(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t, prepareTeamScorecardGapHandlers(t, gh)...)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamScorecardGapRow](100)
+
+	err := listTeamScorecardGaps(ctx, client, writer, "tag1", "Silver", "team-b")
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].TeamTag).To(Equal("team-b"))
+	g.Expect(writer.Items[0].Level.Name).To(Equal("Silver"))
+	g.Expect(writer.Items[0].FailingEntityTags).To(Equal([]string{"service2", "service4"}))
+}
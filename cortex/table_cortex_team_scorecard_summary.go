@@ -0,0 +1,155 @@
+package cortex
+
+import (
+	"context"
+	"sort"
+
+	"github.com/imroc/req/v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+// Used to represent the data we want to return in the table
+type CortexTeamScorecardSummaryRow struct {
+	TeamTag                    string
+	ScorecardTag               string
+	ScorecardName              string
+	EntityCount                int
+	AverageScorePercentage     float64
+	WorstEntityTag             string
+	WorstEntityScorePercentage float64
+}
+
+// Collects the owning team tags of each entity streamed by listEntities.
+type EntityOwnerWriter struct {
+	OwnerTeams map[string][]string
+}
+
+func (w *EntityOwnerWriter) StreamListItem(ctx context.Context, items ...interface{}) {
+	for _, item := range items {
+		if entity, ok := item.(CortexEntityElement); ok {
+			for _, team := range entity.Owners.Teams {
+				w.OwnerTeams[entity.Tag] = append(w.OwnerTeams[entity.Tag], team.Tag)
+			}
+		}
+	}
+}
+
+// The owners of every entity are needed, so never stop early
+func (w *EntityOwnerWriter) RowsRemaining(ctx context.Context) int64 {
+	return 1
+}
+
+func tableCortexTeamScorecardSummary() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_team_scorecard_summary",
+		Description: "Cortex scorecard scores rolled up to the owning teams.",
+		List: &plugin.ListConfig{
+			Hydrate: listTeamScorecardSummariesHydrator,
+			KeyColumns: []*plugin.KeyColumn{
+				{Name: "scorecard_tag", Require: plugin.Required},
+				{Name: "team_tag", Require: plugin.Optional},
+			},
+		},
+		Columns: []*plugin.Column{
+			{Name: "team_tag", Type: proto.ColumnType_STRING, Description: "Tag of the owning team."},
+			{Name: "scorecard_tag", Type: proto.ColumnType_STRING, Description: "Scorecard tag."},
+			{Name: "scorecard_name", Type: proto.ColumnType_STRING, Description: "Scorecard name."},
+			{Name: "entity_count", Type: proto.ColumnType_INT, Description: "Number of scored entities owned by the team.", Transform: transform.FromField("EntityCount")},
+			{Name: "average_score_percentage", Type: proto.ColumnType_DOUBLE, Description: "Average score of the team's entities, as a percentage of the rule weights.", Transform: transform.FromField("AverageScorePercentage")},
+			{Name: "worst_entity_tag", Type: proto.ColumnType_STRING, Description: "Tag of the team's entity with the lowest score."},
+			{Name: "worst_entity_score_percentage", Type: proto.ColumnType_DOUBLE, Description: "Score of the worst entity, as a percentage of the rule weights.", Transform: transform.FromField("WorstEntityScorePercentage")},
+		},
+	}
+}
+
+func listTeamScorecardSummariesHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	scorecardTag := d.EqualsQuals["scorecard_tag"].GetStringValue()
+	teamTag := ""
+	if d.EqualsQuals["team_tag"] != nil {
+		teamTag = d.EqualsQuals["team_tag"].GetStringValue()
+	}
+	logger.Info("listTeamScorecardSummariesHydrator", "scorecardTag", scorecardTag, "teamTag", teamTag)
+	return nil, listTeamScorecardSummaries(ctx, client, &writer, scorecardTag, teamTag)
+}
+
+func listTeamScorecardSummaries(ctx context.Context, client *req.Client, writer HydratorWriter, scorecardTag string, teamTag string) error {
+	scorecard, err := getScorecard(ctx, client, scorecardTag)
+	if err != nil {
+		return err
+	}
+	weights := make(map[string]int)
+	for _, rule := range scorecard.Rules {
+		weights[rule.Identifier] = rule.Weight
+	}
+
+	// Scores only name the entity, the catalog has its owners
+	owners := EntityOwnerWriter{OwnerTeams: make(map[string][]string)}
+	err = listEntities(ctx, client, &owners, "false", "", "", EntityIncludes{Owners: true})
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[string]*CortexTeamScorecardSummaryRow)
+	err = getScorecardScores(ctx, client, scorecardTag, "", func(result *CortexServiceScore) (bool, error) {
+		percentage := scorePercentage(result.Score, weights)
+		for _, team := range owners.OwnerTeams[result.Service.Tag] {
+			if teamTag != "" && team != teamTag {
+				continue
+			}
+			summary, ok := summaries[team]
+			if !ok {
+				summary = &CortexTeamScorecardSummaryRow{TeamTag: team, ScorecardTag: scorecardTag, ScorecardName: scorecard.Name}
+				summaries[team] = summary
+			}
+			if summary.EntityCount == 0 || percentage < summary.WorstEntityScorePercentage {
+				summary.WorstEntityTag = result.Service.Tag
+				summary.WorstEntityScorePercentage = percentage
+			}
+			// Running average over the entities seen so far
+			summary.EntityCount++
+			summary.AverageScorePercentage += (percentage - summary.AverageScorePercentage) / float64(summary.EntityCount)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	teams := make([]string, 0, len(summaries))
+	for team := range summaries {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	for _, team := range teams {
+		// send the item to steampipe
+		writer.StreamListItem(ctx, *summaries[team])
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if writer.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sum of the rule scores as a percentage of the sum of their weights, 0 without weighted rules
+func scorePercentage(score *CortexScore, weights map[string]int) float64 {
+	total, possible := 0, 0
+	for _, rule := range score.Rules {
+		weight, ok := weights[rule.Identifier]
+		if !ok {
+			continue
+		}
+		total += rule.Score
+		possible += weight
+	}
+	if possible == 0 {
+		return 0
+	}
+	return float64(total) * 100 / float64(possible)
+}
@@ -0,0 +1,144 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+func TestTableCortexTeamScorecardSummary(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexTeamScorecardSummary()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_team_scorecard_summary"))
+	g.Expect(table.Description).To(Equal("Cortex scorecard scores rolled up to the owning teams."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(2))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("scorecard_tag"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Required))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("team_tag"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"team_tag", proto.ColumnType_STRING},
+		{"scorecard_tag", proto.ColumnType_STRING},
+		{"scorecard_name", proto.ColumnType_STRING},
+		{"entity_count", proto.ColumnType_INT},
+		{"average_score_percentage", proto.ColumnType_DOUBLE},
+		{"worst_entity_tag", proto.ColumnType_STRING},
+		{"worst_entity_score_percentage", proto.ColumnType_DOUBLE},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func prepareTeamScorecardSummaryHandlers(t *testing.T, gh *ghttp.GHTTPWithGomega) []http.HandlerFunc {
+	t.Helper()
+	scorecard := CortexScorecard{
+		Name: "Production Readiness",
+		Rules: []*CortexRuleInfo{
+			{Identifier: "rule1", LevelName: "Bronze", Weight: 1},
+			{Identifier: "rule2", LevelName: "Silver", Weight: 3},
+		},
+	}
+	entities := []CortexEntityElement{
+		{Tag: "service1", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team-a"}}}},
+		{Tag: "service2", Owners: CortexEntityOwners{Teams: []CortexEntityOwnersTeam{{Tag: "team-a"}, {Tag: "team-b"}}}},
+		{Tag: "service3"},
+	}
+	scores := []*CortexServiceScore{
+		{Service: &CortexEntityElement{Tag: "service1"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 1},
+			{Identifier: "rule2", Score: 3},
+		}}},
+		{Service: &CortexEntityElement{Tag: "service2"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 1},
+			{Identifier: "rule2", Score: 0},
+		}}},
+		{Service: &CortexEntityElement{Tag: "service3"}, Score: &CortexScore{Rules: []*CortexRuleScore{
+			{Identifier: "rule1", Score: 0},
+		}}},
+	}
+	return []http.HandlerFunc{
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1"),
+			gh.RespondWith(http.StatusOK, prepareScorecardResponse(t, scorecard), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusOK, prepareEntityResponse(t, entities, 0, 1, 3), nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/scorecards/tag1/scores"),
+			gh.RespondWith(http.StatusOK, prepareScorecardScoresResponse(t, scores, 0, 1, 3), nil),
+		),
+	}
+}
+
+func TestListTeamScorecardSummaries(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t, prepareTeamScorecardSummaryHandlers(t, gh)...)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamScorecardSummaryRow](100)
+
+	err := listTeamScorecardSummaries(ctx, client, writer, "tag1", "")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(Equal([]CortexTeamScorecardSummaryRow{
+		{
+			TeamTag:                    "team-a",
+			ScorecardTag:               "tag1",
+			ScorecardName:              "Production Readiness",
+			EntityCount:                2,
+			AverageScorePercentage:     62.5,
+			WorstEntityTag:             "service2",
+			WorstEntityScorePercentage: 25,
+		},
+		{
+			TeamTag:                    "team-b",
+			ScorecardTag:               "tag1",
+			ScorecardName:              "Production Readiness",
+			EntityCount:                1,
+			AverageScorePercentage:     25,
+			WorstEntityTag:             "service2",
+			WorstEntityScorePercentage: 25,
+		},
+	}))
+}
+
+func TestListTeamScorecardSummariesForTeam(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t, prepareTeamScorecardSummaryHandlers(t, gh)...)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamScorecardSummaryRow](100)
+
+	err := listTeamScorecardSummaries(ctx, client, writer, "tag1", "team-b")
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].TeamTag).To(Equal("team-b"))
+}
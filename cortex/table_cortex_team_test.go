@@ -1,6 +1,7 @@
 package cortex
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -9,6 +10,8 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 	"gopkg.in/yaml.v3"
 )
 
@@ -36,6 +39,19 @@ func TestTableCortexTeam(t *testing.T) {
 	// Check list configuration.
 	g.Expect(table.List).ToNot(BeNil())
 	g.Expect(table.List.Hydrate).ToNot(BeNil())
+	g.Expect(table.List.KeyColumns).To(HaveLen(4))
+	g.Expect(table.List.KeyColumns[0].Name).To(Equal("include_teams_without_members"))
+	g.Expect(table.List.KeyColumns[0].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[1].Name).To(Equal("source"))
+	g.Expect(table.List.KeyColumns[1].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[2].Name).To(Equal("search"))
+	g.Expect(table.List.KeyColumns[2].Require).To(Equal(plugin.Optional))
+	g.Expect(table.List.KeyColumns[3].Name).To(Equal("max_depth"))
+	g.Expect(table.List.KeyColumns[3].Require).To(Equal(plugin.Optional))
+
+	// Per-row hydrate calls are limited.
+	g.Expect(table.HydrateConfig).To(HaveLen(1))
+	g.Expect(table.HydrateConfig[0].MaxConcurrency).To(Equal(MaxHydrateConcurrency))
 
 	// Define expected columns.
 	expectedColumns := []struct {
@@ -44,13 +60,26 @@ func TestTableCortexTeam(t *testing.T) {
 	}{
 		{"name", proto.ColumnType_STRING},
 		{"tag", proto.ColumnType_STRING},
+		{"id", proto.ColumnType_STRING},
+		{"catalog_entity_tag", proto.ColumnType_STRING},
 		{"parents", proto.ColumnType_JSON},
+		{"parent_tag", proto.ColumnType_STRING},
 		{"children", proto.ColumnType_JSON},
+		{"ancestors", proto.ColumnType_JSON},
+		{"descendant_count", proto.ColumnType_INT},
 		{"metadata", proto.ColumnType_JSON},
 		{"links", proto.ColumnType_JSON},
 		{"archived", proto.ColumnType_BOOL},
 		{"slack_channels", proto.ColumnType_JSON},
+		{"slack_notifications_enabled", proto.ColumnType_BOOL},
 		{"members", proto.ColumnType_JSON},
+		{"member_emails", proto.ColumnType_JSON},
+		{"source", proto.ColumnType_STRING},
+		{"team_type", proto.ColumnType_STRING},
+		{"include_teams_without_members", proto.ColumnType_BOOL},
+		{"search", proto.ColumnType_STRING},
+		{"max_depth", proto.ColumnType_INT},
+		{"error", proto.ColumnType_STRING},
 	}
 
 	// Check that the table has the expected columns.
@@ -85,7 +114,7 @@ func TestListTeamsSinglePage(t *testing.T) {
 		},
 	}
 
-	err := listTeams(ctx, client, writer, relationships)
+	err := listTeams(ctx, client, writer, relationships, "true", "", "", 1)
 	g.Expect(err).To(BeNil())
 
 	g.Expect(writer.Items).To(HaveLen(1))
@@ -94,6 +123,127 @@ func TestListTeamsSinglePage(t *testing.T) {
 	g.Expect(writer.Items[0].Children[0]).To(Equal("child1"))
 	g.Expect(writer.Items[0].Parents).To(HaveLen(1))
 	g.Expect(writer.Items[0].Parents[0]).To(Equal("parent1"))
+	g.Expect(writer.Items[0].ParentTag()).To(Equal("parent1"))
+	g.Expect(writer.Items[0].DescendantCount).To(Equal(1))
+}
+
+func TestListTeamsIdentifiers(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		gh.RespondWith(http.StatusOK, `{"teams": [{"id": "123", "teamTag": "team1", "catalogEntityTag": "team1-entity", "teamType": "IDP"}]}`, nil),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamElement](100)
+	err := listTeams(ctx, client, writer, nil, "true", "", "", 1)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].ID).To(Equal("123"))
+	g.Expect(writer.Items[0].CatalogEntityTag).To(Equal("team1-entity"))
+	g.Expect(writer.Items[0].TeamType).To(Equal("IDP"))
+}
+
+func TestCountDescendants(t *testing.T) {
+	g := NewWithT(t)
+
+	// root has two children, both of which share the same grandchild
+	relationships := map[string]Relationships{
+		"root":       {Children: []string{"child1", "child2"}},
+		"child1":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"child2":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"grandchild": {Parents: []string{"child1", "child2"}},
+	}
+
+	g.Expect(countDescendants("root", relationships)).To(Equal(3))
+	g.Expect(countDescendants("child1", relationships)).To(Equal(1))
+	g.Expect(countDescendants("grandchild", relationships)).To(Equal(0))
+	g.Expect(countDescendants("unknown", relationships)).To(Equal(0))
+}
+
+func TestListTeamsWithoutMembersExcluded(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareTeamResponse(t, []CortexTeamElement{{Tag: "team1"}})
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams", "includeTeamsWithoutMembers=false"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamElement](100)
+
+	err := listTeams(ctx, client, writer, map[string]Relationships{}, "false", "", "", 1)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+}
+
+// Replay the teams API from a cassette, with nulls for the fields a team doesn't use
+func TestListTeamsCassette(t *testing.T) {
+	g := NewWithT(t)
+	ctx, client := setupCassetteClient(t, "teams")
+
+	writer := NewSliceWriter[CortexTeamElement](100)
+	err := listTeams(ctx, client, writer, map[string]Relationships{}, "true", "", "", 1)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(2))
+
+	payments := writer.Items[0]
+	g.Expect(payments.Tag).To(Equal("payments"))
+	g.Expect(payments.TeamType).To(Equal("CORTEX"))
+	g.Expect(payments.Metadata["name"]).To(Equal("Payments"))
+	g.Expect(payments.Links).To(Equal([]CortexLink{{Name: "Runbook", Type: "runbook", Url: "https://wiki.example.com/payments"}}))
+	g.Expect(payments.Slack).To(Equal([]CortexSlackChannel{{Name: "payments-alerts", NotificationsEnabled: true}}))
+	g.Expect(payments.IDPGroup).To(Equal(CortexTeamIDPGroup{}))
+	g.Expect(payments.AllMembers()).To(Equal([]CortexTeamMember{{Name: "Redacted", Email: "user@example.com", NotificationsEnabled: true, Source: "CORTEX"}}))
+
+	platform := writer.Items[1]
+	g.Expect(platform.Tag).To(Equal("platform"))
+	g.Expect(platform.IDPGroup.Group).To(Equal("platform-eng"))
+	g.Expect(platform.IDPGroup.Provider).To(Equal("OKTA"))
+	g.Expect(platform.AllMembers()).To(HaveLen(2))
+	g.Expect(platform.CortexTeam).To(Equal(CortexTeam{}))
+}
+
+func TestListTeamsSourceFilter(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	responseBytes := prepareTeamResponse(t, []CortexTeamElement{
+		{Tag: "okta-team", IDPGroup: CortexTeamIDPGroup{Group: "okta-team", Provider: "OKTA"}},
+		{Tag: "cortex-team"},
+	})
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams"),
+			gh.RespondWith(http.StatusOK, responseBytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexTeamElement](100)
+	err := listTeams(ctx, client, writer, map[string]Relationships{}, "true", "OKTA", "", 1)
+	g.Expect(err).To(BeNil())
+	g.Expect(writer.Items).To(HaveLen(1))
+	g.Expect(writer.Items[0].Tag).To(Equal("okta-team"))
+
+	writer = NewSliceWriter[CortexTeamElement](100)
+	err = listTeams(ctx, client, writer, map[string]Relationships{}, "true", "CORTEX", "", 1)
+	g.Expect(err).To(BeNil())
+	// Teams managed in Cortex have no provider to match
+	g.Expect(writer.Items).To(BeEmpty())
 }
 
 func TestListTeamsError(t *testing.T) {
@@ -113,7 +263,7 @@ func TestListTeamsError(t *testing.T) {
 
 	relationships := map[string]Relationships{}
 
-	err := listTeams(ctx, client, writer, relationships)
+	err := listTeams(ctx, client, writer, relationships, "true", "", "", 1)
 	g.Expect(err).ToNot(BeNil())
 	g.Expect(err.Error()).To(Equal("error from cortex API 500 Internal Server Error: {\"details\": \"fake error on teams\"}"))
 }
@@ -193,3 +343,113 @@ func TestGetTeamRelationshipsInvalidYAML(t *testing.T) {
 	g.Expect(err).ToNot(BeNil())
 	g.Expect(relationships).To(BeNil())
 }
+
+func TestTeamMemberEmails(t *testing.T) {
+	g := NewWithT(t)
+	members := CortexTeamMembers{Members: []CortexTeamMember{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	}}
+
+	value, err := getColumn(tableCortexTeam(), "member_emails").Transform.Execute(context.Background(), &transform.TransformData{HydrateItem: members})
+	g.Expect(err).To(BeNil())
+	g.Expect(value).To(Equal([]string{"alice@example.com", "bob@example.com"}))
+}
+
+func TestGetTeamMembers(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	fullTeam, err := yaml.Marshal(CortexTeamElement{Tag: "team1", IDPGroup: CortexTeamIDPGroup{Provider: "OKTA", Members: []CortexTeamMember{
+		{Name: "Alice", Email: "alice@example.com", Role: "manager"},
+	}}})
+	g.Expect(err).To(BeNil())
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/team1"),
+			gh.RespondWith(http.StatusOK, fullTeam, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/teams/team2"),
+			gh.RespondWith(http.StatusNotFound, "{}", nil),
+		),
+	)
+	defer server.Close()
+
+	members, err := getTeamMembers(ctx, client, CortexTeamElement{Tag: "team1"})
+	g.Expect(err).To(BeNil())
+	g.Expect(members.Members).To(Equal([]CortexTeamMember{{Name: "Alice", Email: "alice@example.com", Role: "manager", Source: "OKTA"}}))
+
+	// Falls back to the listed members
+	listed := CortexTeamElement{Tag: "team2", CortexTeam: CortexTeam{Members: []CortexTeamMember{{Email: "bob@example.com"}}}}
+	members, err = getTeamMembers(ctx, client, listed)
+	g.Expect(err).To(BeNil())
+	g.Expect(members.Members).To(Equal([]CortexTeamMember{{Email: "bob@example.com", Source: "CORTEX"}}))
+}
+
+func TestTeamAllMembers(t *testing.T) {
+	g := NewWithT(t)
+	team := CortexTeamElement{
+		IDPGroup: CortexTeamIDPGroup{Provider: "OKTA", Members: []CortexTeamMember{
+			{Email: "alice@example.com", Role: "manager"},
+		}},
+		CortexTeam: CortexTeam{Members: []CortexTeamMember{
+			{Email: "bob@example.com", Role: "member"},
+		}},
+	}
+
+	g.Expect(team.AllMembers()).To(Equal([]CortexTeamMember{
+		{Email: "alice@example.com", Role: "manager", Source: "OKTA"},
+		{Email: "bob@example.com", Role: "member", Source: "CORTEX"},
+	}))
+}
+
+func TestTeamMatches(t *testing.T) {
+	g := NewWithT(t)
+	team := CortexTeamElement{Tag: "payments-team", Metadata: map[string]interface{}{"name": "Payments Platform"}}
+
+	g.Expect(team.Matches("PLATFORM")).To(BeTrue())
+	g.Expect(team.Matches("payments-team")).To(BeTrue())
+	g.Expect(team.Matches("billing")).To(BeFalse())
+}
+
+func TestTeamAncestors(t *testing.T) {
+	g := NewWithT(t)
+
+	// grandchild has two parents which share the root
+	relationships := map[string]Relationships{
+		"root":       {Children: []string{"child1", "child2"}},
+		"child1":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"child2":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"grandchild": {Parents: []string{"child1", "child2"}},
+	}
+
+	g.Expect(teamAncestors("grandchild", relationships)).To(Equal([]string{"root", "child2", "child1"}))
+	g.Expect(teamAncestors("child1", relationships)).To(Equal([]string{"root"}))
+	g.Expect(teamAncestors("root", relationships)).To(BeEmpty())
+}
+
+func TestTeamParentTag(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(CortexTeamElement{Parents: []string{"parent1", "parent2"}}.ParentTag()).To(Equal("parent1"))
+	g.Expect(CortexTeamElement{}.ParentTag()).To(BeEmpty())
+}
+
+func TestTeamChildren(t *testing.T) {
+	g := NewWithT(t)
+
+	relationships := map[string]Relationships{
+		"root":       {Children: []string{"child1", "child2"}},
+		"child1":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"child2":     {Parents: []string{"root"}, Children: []string{"grandchild"}},
+		"grandchild": {Parents: []string{"child1", "child2"}},
+	}
+
+	g.Expect(teamChildren("root", relationships, 1)).To(Equal([]string{"child1", "child2"}))
+	g.Expect(teamChildren("root", relationships, 0)).To(Equal([]string{"child1", "child2"}))
+	g.Expect(teamChildren("root", relationships, 2)).To(Equal([]string{"child1", "child2", "grandchild"}))
+	g.Expect(teamChildren("root", relationships, 10)).To(Equal([]string{"child1", "child2", "grandchild"}))
+	g.Expect(teamChildren("grandchild", relationships, 2)).To(BeEmpty())
+}
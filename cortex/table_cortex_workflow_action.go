@@ -0,0 +1,119 @@
+package cortex
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+type CortexWorkflowsResponse struct {
+	Workflows  []CortexWorkflow `yaml:"workflows"`
+	Page       int              `yaml:"page"`
+	TotalPages int              `yaml:"totalPages"`
+	Total      int              `yaml:"total"`
+}
+
+func (r CortexWorkflowsResponse) Pagination() cortexapi.Pagination {
+	return cortexapi.Pagination{Page: r.Page, TotalPages: r.TotalPages}
+}
+
+type CortexWorkflow struct {
+	Tag         string                 `yaml:"tag"`
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	IsDraft     bool                   `yaml:"isDraft"`
+	Actions     []CortexWorkflowAction `yaml:"actions"`
+}
+
+type CortexWorkflowAction struct {
+	Slug            string                 `yaml:"slug"`
+	Name            string                 `yaml:"name"`
+	IsRootAction    bool                   `yaml:"isRootAction"`
+	OutgoingActions []string               `yaml:"outgoingActions"`
+	Schema          map[string]interface{} `yaml:"schema"`
+}
+
+// The kind of action, e.g. HTTP_REQUEST or MANUAL_APPROVAL
+func (a CortexWorkflowAction) Type() string {
+	actionType, _ := a.Schema["type"].(string)
+	return actionType
+}
+
+// Used to represent the data we want to return in the table
+type CortexWorkflowActionRow struct {
+	WorkflowTag   string
+	WorkflowName  string
+	WorkflowDraft bool
+	ActionIndex   int
+	Action        CortexWorkflowAction
+}
+
+func tableCortexWorkflowAction() *plugin.Table {
+	return &plugin.Table{
+		Name:        "cortex_workflow_action",
+		Description: "Cortex actions of each workflow.",
+		List: &plugin.ListConfig{
+			Hydrate: listWorkflowActionsHydrator,
+		},
+		Columns: []*plugin.Column{
+			{Name: "workflow_tag", Type: proto.ColumnType_STRING, Description: "Workflow tag."},
+			{Name: "workflow_name", Type: proto.ColumnType_STRING, Description: "Workflow name."},
+			{Name: "workflow_draft", Type: proto.ColumnType_BOOL, Description: "Whether the workflow is a draft.", Transform: transform.FromField("WorkflowDraft")},
+			{Name: "action_index", Type: proto.ColumnType_INT, Description: "Position of the action in the workflow, starting at 0.", Transform: transform.FromField("ActionIndex")},
+			{Name: "slug", Type: proto.ColumnType_STRING, Description: "Action slug, unique within the workflow.", Transform: transform.FromField("Action.Slug")},
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Action name.", Transform: transform.FromField("Action.Name")},
+			{Name: "type", Type: proto.ColumnType_STRING, Description: "Type of the action, e.g. HTTP_REQUEST or MANUAL_APPROVAL.", Transform: transform.FromField("Action").Transform(workflowActionType)},
+			{Name: "root_action", Type: proto.ColumnType_BOOL, Description: "Whether the workflow starts with this action.", Transform: transform.FromField("Action.IsRootAction")},
+			{Name: "outgoing_actions", Type: proto.ColumnType_JSON, Description: "Slugs of the actions run after this one.", Transform: transform.FromField("Action.OutgoingActions")},
+			{Name: "configuration", Type: proto.ColumnType_JSON, Description: "Configuration of the action, e.g. the URL and method of an HTTP request.", Transform: transform.FromField("Action.Schema")},
+		},
+	}
+}
+
+func workflowActionType(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	action, ok := d.Value.(CortexWorkflowAction)
+	if !ok || action.Type() == "" {
+		return nil, nil
+	}
+	return action.Type(), nil
+}
+
+func listWorkflowActionsHydrator(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	config := GetTableConfig(d)
+	client := CortexHTTPClient(ctx, config)
+	writer := QueryDataWriter{d}
+	return nil, listWorkflowActions(ctx, client, &writer)
+}
+
+func listWorkflowActions(ctx context.Context, client *req.Client, writer HydratorWriter) error {
+	request := func() *req.Request {
+		return client.
+			Get("/api/v1/workflows").
+			// Options
+			SetQueryParam("includeActions", "true")
+	}
+	return cortexapi.Paginate(ctx, request, func(response CortexWorkflowsResponse) (bool, error) {
+		// Stream a row for each action, stop if we hit the limit
+		for _, workflow := range response.Workflows {
+			for i, action := range workflow.Actions {
+				// send the item to steampipe
+				writer.StreamListItem(ctx, CortexWorkflowActionRow{
+					WorkflowTag:   workflow.Tag,
+					WorkflowName:  workflow.Name,
+					WorkflowDraft: workflow.IsDraft,
+					ActionIndex:   i,
+					Action:        action,
+				})
+				// Context can be cancelled due to manual cancellation or the limit has been hit
+				if writer.RowsRemaining(ctx) == 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,104 @@
+package cortex
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"gopkg.in/yaml.v3"
+)
+
+func prepareWorkflowResponse(t *testing.T, workflows []CortexWorkflow, page, totalPages, total int) []byte {
+	t.Helper()
+	response := CortexWorkflowsResponse{
+		Workflows:  workflows,
+		Page:       page,
+		TotalPages: totalPages,
+		Total:      total,
+	}
+	responseBytes, err := yaml.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	return responseBytes
+}
+
+func TestTableCortexWorkflowAction(t *testing.T) {
+	g := NewWithT(t)
+	table := tableCortexWorkflowAction()
+
+	// Check basic table properties.
+	g.Expect(table).ToNot(BeNil())
+	g.Expect(table.Name).To(Equal("cortex_workflow_action"))
+	g.Expect(table.Description).To(Equal("Cortex actions of each workflow."))
+
+	// Check list configuration.
+	g.Expect(table.List).ToNot(BeNil())
+	g.Expect(table.List.Hydrate).ToNot(BeNil())
+
+	// Define expected columns.
+	expectedColumns := []struct {
+		Name string
+		Type proto.ColumnType
+	}{
+		{"workflow_tag", proto.ColumnType_STRING},
+		{"workflow_name", proto.ColumnType_STRING},
+		{"workflow_draft", proto.ColumnType_BOOL},
+		{"action_index", proto.ColumnType_INT},
+		{"slug", proto.ColumnType_STRING},
+		{"name", proto.ColumnType_STRING},
+		{"type", proto.ColumnType_STRING},
+		{"root_action", proto.ColumnType_BOOL},
+		{"outgoing_actions", proto.ColumnType_JSON},
+		{"configuration", proto.ColumnType_JSON},
+	}
+
+	// Check that the table has the expected columns.
+	g.Expect(table.Columns).To(HaveLen(len(expectedColumns)))
+	for i, exp := range expectedColumns {
+		g.Expect(table.Columns[i].Name).To(Equal(exp.Name))
+		g.Expect(table.Columns[i].Type).To(Equal(exp.Type))
+	}
+}
+
+func TestListWorkflowActions(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	respPage0Bytes := prepareWorkflowResponse(t, []CortexWorkflow{
+		{Tag: "deploy", Name: "Deploy", Actions: []CortexWorkflowAction{
+			{Slug: "approve", IsRootAction: true, OutgoingActions: []string{"call"}, Schema: map[string]interface{}{"type": "MANUAL_APPROVAL"}},
+			{Slug: "call", Schema: map[string]interface{}{"type": "HTTP_REQUEST", "url": "https://example.com"}},
+		}},
+	}, 0, 2, 2)
+	respPage1Bytes := prepareWorkflowResponse(t, []CortexWorkflow{
+		{Tag: "empty", Name: "Empty"},
+	}, 1, 2, 2)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/workflows", "includeActions=true&page=0&pageSize=1000"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, respPage0Bytes, nil),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/workflows", "includeActions=true&page=1&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, respPage1Bytes, nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexWorkflowActionRow](100)
+
+	err := listWorkflowActions(ctx, client, writer)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(writer.Items).To(HaveLen(2))
+	g.Expect(writer.Items[0].WorkflowTag).To(Equal("deploy"))
+	g.Expect(writer.Items[0].ActionIndex).To(Equal(0))
+	g.Expect(writer.Items[0].Action.Type()).To(Equal("MANUAL_APPROVAL"))
+	g.Expect(writer.Items[1].ActionIndex).To(Equal(1))
+	g.Expect(writer.Items[1].Action.Schema).To(HaveKeyWithValue("url", "https://example.com"))
+}
@@ -2,24 +2,242 @@ package cortex
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/imroc/req/v3"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
 	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
-	"gopkg.in/yaml.v3"
+	"github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Create a req http client for the Cortex API.
-// This will set the BaseURL and Auth from config, as well as common retry settings.
+// This will set the BaseURL and Auth from config, and limit retries to the retry budget.
+// With several api_keys each request uses the next key that isn't rate limited.
+// Clients are created per hydrate call, the retry budget and deadline are shared by every client of the scan
+// from GetTableConfig, including per-row hydrates, while the transport and its HTTP/2 or kept-alive connections
+// are shared by every scan of the connection.
+// With source "file" the client answers from the fixtures instead.
 func CortexHTTPClient(ctx context.Context, config *SteampipeConfig) *req.Client {
-	return req.C().
-		SetBaseURL(*config.BaseURL).
-		SetJsonUnmarshal(yaml.Unmarshal).
-		SetCommonRetryCount(2).
-		SetCommonRetryBackoffInterval(time.Second, 5*time.Second).
-		SetCommonBearerAuthToken(*config.ApiKey)
+	if config.GetSource() == SourceFile {
+		return fixtureHTTPClient(*config.BaseURL, *config.FixturesDir)
+	}
+	budget := getScanRetryBudget(ctx, config.connectionKey(), config.scan, config.GetRetryBudget(), config.GetScanTimeout())
+	breaker := getCircuitBreaker(*config.BaseURL)
+	keys := getAPIKeyPool(*config.BaseURL, config.GetApiKeys())
+	requests := getConnectionSemaphore("requests/"+*config.BaseURL, config.GetMaxConcurrentRequests())
+	transport := getConnectionTransport(*config.BaseURL, config.GetMaxConcurrentRequests())
+	return cortexapi.SetTransport(cortexapi.NewClient(*config.BaseURL, ""), transport).
+		// Only GET requests are retried after a failed connection, a throttled call of any method
+		// was rejected by Cortex, so it can be retried with another key
+		SetCommonRetryCondition(func(resp *req.Response, err error) bool {
+			failed := err != nil && cortexapi.IsSafeMethod(resp.Request.Method)
+			throttled := resp.GetStatusCode() == http.StatusTooManyRequests && keys.size() > 1
+			return (failed || throttled) && budget.take()
+		}).
+		OnBeforeRequest(func(c *req.Client, r *req.Request) error {
+			if budget.expired() {
+				return fmt.Errorf("cortex API calls did not complete within the scan timeout of %s", budget.timeout)
+			}
+			if err := breaker.allow(); err != nil {
+				return err
+			}
+			// Runs again for each retry, so a retry may use a different key
+			apiKey := keys.pick(time.Now())
+			r.SetBearerAuthToken(apiKey)
+			return getCredentialCheck(*config.BaseURL, apiKey).run(ctx, *config.BaseURL, apiKey)
+		}).
+		OnAfterResponse(func(c *req.Client, resp *req.Response) error {
+			// A cancelled call neither opens nor closes the breaker
+			if !isCancelled(resp) {
+				breaker.record(isAPIFailure(resp))
+			}
+			keys.record(requestAPIKey(resp.Request), resp, time.Now())
+			recordRateLimit(config.connectionKey(), resp)
+			recordCallStats(config.connectionKey(), resp)
+			plugin.Logger(ctx).Debug("CortexHTTPClient", "URL", resp.Request.RawURL, "Status", resp.GetStatus(), "RequestID", resp.GetHeader(cortexapi.RequestIDHeader))
+			return nil
+		}).
+		WrapRoundTripFunc(traceRoundTrip, limitRoundTrip(requests))
+}
+
+// Record a span for every HTTP call, including retries, in steampipe's OTEL pipeline
+func traceRoundTrip(rt req.RoundTripper) req.RoundTripFunc {
+	return func(r *req.Request) (*req.Response, error) {
+		_, span := telemetry.StartSpan(r.Context(), PluginName, "CortexHTTPClient %s %s", r.Method, r.URL.Path)
+		defer span.End()
+		resp, err := rt.RoundTrip(r)
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.path", r.URL.Path),
+			attribute.Int("http.status_code", resp.GetStatusCode()),
+			attribute.String("cortex.request_id", resp.GetHeader(cortexapi.RequestIDHeader)),
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		} else if resp.GetStatusCode() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.GetStatus())
+		}
+		return resp, err
+	}
+}
+
+// Wait for a slot in the semaphore before each HTTP call, including retries, so parallel hydrates share one cap
+func limitRoundTrip(semaphore chan struct{}) req.RoundTripWrapperFunc {
+	return func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			select {
+			case semaphore <- struct{}{}:
+			case <-r.Context().Done():
+				return &req.Response{Request: r}, r.Context().Err()
+			}
+			defer func() { <-semaphore }()
+			return rt.RoundTrip(r)
+		}
+	}
+}
+
+// With ignore_row_errors a failed per-row hydrate is recorded in the row's error column rather than failing the scan.
+// Returns the message to record, or the error if it should fail the scan.
+func rowError(ctx context.Context, d *plugin.QueryData, err error) (string, error) {
+	if !GetConfig(d.Connection).GetIgnoreRowErrors() {
+		return "", err
+	}
+	plugin.Logger(ctx).Warn("rowError", "Table", d.Table.Name, "Error", err)
+	return err.Error(), nil
+}
+
+// With ignore_error_codes, list and hydrate calls failing with one of the codes return no data rather than failing the query
+func shouldIgnoreErrors(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+	var apiErr *cortexapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range GetConfig(d.Connection).IgnoreErrorCodes {
+		if apiErr.StatusCode == code {
+			plugin.Logger(ctx).Warn("shouldIgnoreErrors", "Table", d.Table.Name, "Status", apiErr.Status, "RequestID", apiErr.RequestID)
+			return true
+		}
+	}
+	return false
+}
+
+// Retries shared by every request of a client, so a flapping endpoint cannot
+// multiply the latency of a scan that makes many calls.
+type retryBudget struct {
+	mu        sync.Mutex
+	remaining int
+	timeout   time.Duration
+	deadline  time.Time
+}
+
+func newRetryBudget(retries int, timeout time.Duration) *retryBudget {
+	return &retryBudget{remaining: retries, timeout: timeout, deadline: time.Now().Add(timeout)}
+}
+
+// Use up one retry, false if none are left or the deadline has passed
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining <= 0 || time.Now().After(b.deadline) {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+func (b *retryBudget) expired() bool {
+	return time.Now().After(b.deadline)
+}
+
+// Retry budgets of running scans, keyed by the connection and the scan's query context.
+// Steampipe passes the same query context to every hydrate call of a scan. If it ever didn't,
+// each hydrate call would have a budget of its own rather than sharing one wrongly.
+var scanRetryBudgets = struct {
+	sync.Mutex
+	byScan map[scanKey]*retryBudget
+}{byScan: make(map[scanKey]*retryBudget)}
+
+type scanKey struct {
+	connection string
+	scan       *plugin.QueryContext
+}
+
+// The retry budget of the scan, created by its first client. Without a scan, e.g. in tests, each client has its own.
+// A budget is forgotten when the context of the hydrate call that created it is done, which is when the scan ends,
+// or at the latest once it is well past its deadline, every call of the scan fails by then anyway.
+func getScanRetryBudget(ctx context.Context, connection string, scan *plugin.QueryContext, retries int, timeout time.Duration) *retryBudget {
+	if scan == nil {
+		return newRetryBudget(retries, timeout)
+	}
+	scanRetryBudgets.Lock()
+	defer scanRetryBudgets.Unlock()
+	now := time.Now()
+	for key, budget := range scanRetryBudgets.byScan {
+		if now.After(budget.deadline.Add(budget.timeout)) {
+			delete(scanRetryBudgets.byScan, key)
+		}
+	}
+	key := scanKey{connection, scan}
+	budget, ok := scanRetryBudgets.byScan[key]
+	if !ok {
+		budget = newRetryBudget(retries, timeout)
+		scanRetryBudgets.byScan[key] = budget
+		context.AfterFunc(ctx, func() {
+			scanRetryBudgets.Lock()
+			defer scanRetryBudgets.Unlock()
+			if scanRetryBudgets.byScan[key] == budget {
+				delete(scanRetryBudgets.byScan, key)
+			}
+		})
+	}
+	return budget
+}
+
+// Semaphores shared by every scan of a connection, steampipe runs the list call
+// for each value of an IN (...) qual concurrently.
+var connectionSemaphores = struct {
+	sync.Mutex
+	byName map[string]chan struct{}
+}{byName: make(map[string]chan struct{})}
+
+// Get the semaphore with the given name, the size is set when it is first created
+func getConnectionSemaphore(name string, size int) chan struct{} {
+	connectionSemaphores.Lock()
+	defer connectionSemaphores.Unlock()
+	semaphore, ok := connectionSemaphores.byName[name]
+	if !ok {
+		semaphore = make(chan struct{}, size)
+		connectionSemaphores.byName[name] = semaphore
+	}
+	return semaphore
+}
+
+// Transports shared by every scan of a connection, so scans and per-row hydrates reuse connections
+var connectionTransports = struct {
+	sync.Mutex
+	byBaseURL map[string]*req.Transport
+}{byBaseURL: make(map[string]*req.Transport)}
+
+// Get the transport for the base URL, enough idle connections are kept for the requests allowed at once
+func getConnectionTransport(baseURL string, maxConcurrentRequests int) *req.Transport {
+	connectionTransports.Lock()
+	defer connectionTransports.Unlock()
+	transport, ok := connectionTransports.byBaseURL[baseURL]
+	if !ok {
+		transport = cortexapi.NewTransport()
+		transport.MaxIdleConnsPerHost = maxConcurrentRequests
+		connectionTransports.byBaseURL[baseURL] = transport
+	}
+	return transport
 }
 
 // Get field from the data and for each item of type T, get the nested field "child"
@@ -51,6 +269,94 @@ func TagArrayToMap(ctx context.Context, d *transform.TransformData) (interface{}
 	return result, nil
 }
 
+// Empty or nil arrays and objects are null, so IS NULL matches a column with nothing in it
+func EmptyCollectionToNull(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	value := reflect.Indirect(reflect.ValueOf(d.Value))
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		if value.Len() == 0 {
+			return nil, nil
+		}
+	case reflect.Invalid:
+		return nil, nil
+	}
+	return d.Value, nil
+}
+
+// Nil arrays and objects are [] and {}, so JSON functions work on a column with nothing in it.
+// Nil values of an unknown type stay null.
+func NullCollectionToEmpty(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	value := reflect.Indirect(reflect.ValueOf(d.Value))
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.IsNil() {
+			return []interface{}{}, nil
+		}
+	case reflect.Map:
+		if value.IsNil() {
+			return map[string]interface{}{}, nil
+		}
+	case reflect.Invalid:
+		return nil, nil
+	}
+	return d.Value, nil
+}
+
+// Layouts used by the Cortex API for dates, those without a zone are UTC.
+var cortexTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Parse a Cortex date string into a UTC time.
+func ParseCortexTime(value string) (time.Time, error) {
+	for _, layout := range cortexTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %q as a timestamp", value)
+}
+
+// Parse a Cortex date string into a UTC time, empty strings are null.
+// Values in an unknown format are logged and null rather than failing the whole scan.
+func ToUTCTimestamp(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	value, ok := d.Value.(string)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	t, err := ParseCortexTime(value)
+	if err != nil {
+		plugin.Logger(ctx).Warn("ToUTCTimestamp", "Column", d.ColumnName, "Error", err)
+		return nil, nil
+	}
+	return t, nil
+}
+
+// True when any of the slack channels in the field have notifications enabled
+func AnySlackNotificationsEnabled(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	channels, ok := d.Value.([]CortexSlackChannel)
+	if !ok {
+		return false, nil
+	}
+	for _, channel := range channels {
+		if channel.NotificationsEnabled {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// True when the field is null, e.g. an optional qual that defaults to true
+func NullToTrue(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	if d.Value == nil {
+		return true, nil
+	}
+	return d.Value, nil
+}
+
 // Writer is a generic interface to stream items of any type.
 type HydratorWriter interface {
 	StreamListItem(ctx context.Context, items ...interface{})
@@ -0,0 +1,310 @@
+package cortex
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/imroc/req/v3"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/smirl/steampipe-plugin-cortex/pkg/cortexapi"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestToUTCTimestamp(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	tests := []struct {
+		Value    interface{}
+		Expected interface{}
+	}{
+		{"2025-05-02T12:00:00Z", time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)},
+		{"2025-05-02T14:00:00.5+02:00", time.Date(2025, 5, 2, 12, 0, 0, 500000000, time.UTC)},
+		{"2025-05-02T12:00:00.123", time.Date(2025, 5, 2, 12, 0, 0, 123000000, time.UTC)},
+		{"2025-05-02", time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)},
+		{"2025-05-02T14:00:00+0200", time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)},
+		{"2025-05-02T14:00:00.25+0200", time.Date(2025, 5, 2, 12, 0, 0, 250000000, time.UTC)},
+	}
+	for _, test := range tests {
+		value, err := ToUTCTimestamp(ctx, &transform.TransformData{Value: test.Value})
+		g.Expect(err).To(BeNil())
+		g.Expect(value).To(Equal(test.Expected))
+	}
+
+	// Empty values are null rather than the zero time
+	for _, empty := range []interface{}{"", nil} {
+		value, err := ToUTCTimestamp(ctx, &transform.TransformData{Value: empty})
+		g.Expect(err).To(BeNil())
+		g.Expect(value).To(BeNil())
+	}
+
+	// Unknown formats are null so the rest of the scan still works
+	value, err := ToUTCTimestamp(ctx, &transform.TransformData{Value: "yesterday"})
+	g.Expect(err).To(BeNil())
+	g.Expect(value).To(BeNil())
+	_, err = ParseCortexTime("yesterday")
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestNullToTrue(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		Value    interface{}
+		Expected interface{}
+	}{
+		{nil, true},
+		{true, true},
+		{false, false},
+	}
+	for _, test := range tests {
+		value, err := NullToTrue(ctx, &transform.TransformData{Value: test.Value})
+		g.Expect(err).To(BeNil())
+		g.Expect(value).To(Equal(test.Expected))
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	g := NewWithT(t)
+
+	budget := newRetryBudget(2, time.Minute)
+	g.Expect(budget.take()).To(BeTrue())
+	g.Expect(budget.take()).To(BeTrue())
+	g.Expect(budget.take()).To(BeFalse())
+	g.Expect(budget.expired()).To(BeFalse())
+
+	expired := newRetryBudget(2, -time.Second)
+	g.Expect(expired.take()).To(BeFalse())
+	g.Expect(expired.expired()).To(BeTrue())
+}
+
+func TestScanRetryBudget(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// Clients of the same scan, e.g. per-row hydrates, share the budget
+	scan := &plugin.QueryContext{}
+	budget := getScanRetryBudget(ctx, "cortex", scan, 1, time.Minute)
+	g.Expect(getScanRetryBudget(ctx, "cortex", scan, 1, time.Minute)).To(BeIdenticalTo(budget))
+	g.Expect(budget.take()).To(BeTrue())
+	g.Expect(getScanRetryBudget(ctx, "cortex", scan, 1, time.Minute).take()).To(BeFalse())
+
+	// Other scans, other connections and clients without a scan have their own
+	g.Expect(getScanRetryBudget(ctx, "cortex", &plugin.QueryContext{}, 1, time.Minute)).ToNot(BeIdenticalTo(budget))
+	g.Expect(getScanRetryBudget(ctx, "cortex_other", scan, 1, time.Minute)).ToNot(BeIdenticalTo(budget))
+	g.Expect(getScanRetryBudget(ctx, "cortex", nil, 1, time.Minute)).ToNot(BeIdenticalTo(getScanRetryBudget(ctx, "cortex", nil, 1, time.Minute)))
+
+	// Budgets well past their deadline are forgotten
+	old := &plugin.QueryContext{}
+	getScanRetryBudget(ctx, "cortex", old, 1, -time.Second)
+	getScanRetryBudget(ctx, "cortex", scan, 1, time.Minute)
+	scanRetryBudgets.Lock()
+	_, kept := scanRetryBudgets.byScan[scanKey{"cortex", old}]
+	g.Expect(kept).To(BeFalse())
+	_, kept = scanRetryBudgets.byScan[scanKey{"cortex", scan}]
+	g.Expect(kept).To(BeTrue())
+	scanRetryBudgets.Unlock()
+
+	// And so are budgets of scans that have ended
+	ended := &plugin.QueryContext{}
+	scanCtx, cancel := context.WithCancel(ctx)
+	getScanRetryBudget(scanCtx, "cortex", ended, 1, time.Minute)
+	cancel()
+	g.Eventually(func() bool {
+		scanRetryBudgets.Lock()
+		defer scanRetryBudgets.Unlock()
+		_, kept := scanRetryBudgets.byScan[scanKey{"cortex", ended}]
+		return kept
+	}).Should(BeFalse())
+}
+
+func TestCortexHTTPClientScanTimeout(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewServer()
+	defer server.Close()
+
+	config := NewSteampipeConfig("fake_api_key", server.URL())
+	scanTimeout := "1ns"
+	config.ScanTimeout = &scanTimeout
+	client := CortexHTTPClient(ctx, config)
+	time.Sleep(time.Millisecond)
+
+	resp := client.Get("/api/v1/catalog").Do(ctx)
+	g.Expect(resp.Err).ToNot(BeNil())
+	g.Expect(resp.Err.Error()).To(Equal("cortex API calls did not complete within the scan timeout of 1ns"))
+	g.Expect(server.ReceivedRequests()).To(BeEmpty())
+}
+
+func TestCortexAPIErrorRequestID(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusBadRequest, "{}", http.Header{cortexapi.RequestIDHeader: []string{"abc-123"}}),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(Equal("error from cortex API 400 Bad Request (request id abc-123): {}"))
+}
+
+func TestCortexHTTPClientSpans(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	ctx, server, client := setupTestServerAndClient(t,
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/catalog"),
+			gh.RespondWith(http.StatusOK, prepareEntityResponse(t, nil, 0, 1, 0), nil),
+		),
+	)
+	defer server.Close()
+
+	writer := NewSliceWriter[CortexEntityElement](100)
+	err := listEntities(ctx, client, writer, "false", "", "", AllEntityIncludes)
+	g.Expect(err).To(BeNil())
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].Name()).To(Equal("CortexHTTPClient GET /api/v1/catalog"))
+	g.Expect(spans[0].Attributes()).To(ContainElement(attribute.Int("http.status_code", http.StatusOK)))
+}
+
+func TestGetConnectionSemaphore(t *testing.T) {
+	g := NewWithT(t)
+
+	semaphore := getConnectionSemaphore("test/connection", 2)
+	g.Expect(cap(semaphore)).To(Equal(2))
+	// The size of an existing semaphore is kept
+	g.Expect(getConnectionSemaphore("test/connection", 5)).To(Equal(semaphore))
+	g.Expect(getConnectionSemaphore("test/other", 5)).ToNot(Equal(semaphore))
+}
+
+func TestCortexHTTPClientReusesConnections(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+
+	server := ghttp.NewUnstartedServer()
+	var connections atomic.Int32
+	server.HTTPTestServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"tag": "service1"}`),
+		gh.RespondWith(http.StatusOK, `{"tag": "service2"}`),
+	)
+	getCredentialCheck(server.URL(), "fake_api_key").done = true
+
+	// Each scan creates a client, they share the connection's transport
+	for _, tag := range []string{"service1", "service2"} {
+		client := CortexHTTPClient(ctx, NewSteampipeConfig("fake_api_key", server.URL()))
+		_, err := getEntity(ctx, client, tag, EntityIncludes{})
+		g.Expect(err).To(BeNil())
+	}
+	g.Expect(connections.Load()).To(Equal(int32(1)))
+}
+
+func TestLimitRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	semaphore := make(chan struct{}, 2)
+
+	var inFlight, maxInFlight int32
+	roundTrip := limitRoundTrip(semaphore)(req.RoundTripFunc(func(r *req.Request) (*req.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			current := atomic.LoadInt32(&maxInFlight)
+			if n <= current || atomic.CompareAndSwapInt32(&maxInFlight, current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return &req.Response{Request: r}, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := roundTrip.RoundTrip(req.C().R())
+			g.Expect(err).To(BeNil())
+		}()
+	}
+	wg.Wait()
+	g.Expect(maxInFlight).To(Equal(int32(2)))
+
+	// Waiting for a slot stops when the request is cancelled
+	semaphore <- struct{}{}
+	semaphore <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := roundTrip.RoundTrip(req.C().R().SetContext(ctx))
+	g.Expect(err).To(Equal(context.Canceled))
+}
+
+func TestRowError(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+	failure := fmt.Errorf("error from cortex API 500 Internal Server Error: {}")
+
+	// Fails the scan by default
+	d := &plugin.QueryData{Table: &plugin.Table{Name: "cortex_entity"}, Connection: &plugin.Connection{Config: SteampipeConfig{}}}
+	message, err := rowError(ctx, d, failure)
+	g.Expect(err).To(Equal(failure))
+	g.Expect(message).To(BeEmpty())
+
+	ignore := true
+	d.Connection = &plugin.Connection{Config: SteampipeConfig{IgnoreRowErrors: &ignore}}
+	message, err = rowError(ctx, d, failure)
+	g.Expect(err).To(BeNil())
+	g.Expect(message).To(Equal("error from cortex API 500 Internal Server Error: {}"))
+}
+
+func TestShouldIgnoreErrors(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.WithValue(context.Background(), context_key.Logger, hclog.NewNullLogger())
+	forbidden := fmt.Errorf("listing: %w", &cortexapi.Error{StatusCode: http.StatusForbidden, Status: "403 Forbidden"})
+	failure := &cortexapi.Error{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error"}
+
+	// Nothing is ignored by default
+	d := &plugin.QueryData{Table: &plugin.Table{Name: "cortex_entity"}, Connection: &plugin.Connection{Config: SteampipeConfig{}}}
+	g.Expect(shouldIgnoreErrors(ctx, d, nil, forbidden)).To(BeFalse())
+
+	d.Connection = &plugin.Connection{Config: SteampipeConfig{IgnoreErrorCodes: []int{403, 404}}}
+	g.Expect(shouldIgnoreErrors(ctx, d, nil, forbidden)).To(BeTrue())
+	g.Expect(shouldIgnoreErrors(ctx, d, nil, failure)).To(BeFalse())
+	g.Expect(shouldIgnoreErrors(ctx, d, nil, fmt.Errorf("not from the API"))).To(BeFalse())
+}
@@ -0,0 +1,199 @@
+package cortexapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/imroc/req/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Whether a Recorder calls the API and records the responses, or replays them from the cassette
+type RecorderMode int
+
+const (
+	ModeReplay RecorderMode = iota
+	ModeRecord
+)
+
+// Headers kept in a cassette, everything else such as cookies is dropped
+var cassetteHeaders = []string{"Content-Type", RequestIDHeader}
+
+// Calls recorded to a cassette file, only the path and query of the URL are kept
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Method string            `yaml:"method"`
+	URL    string            `yaml:"url"`
+	Status int               `yaml:"status"`
+	Header map[string]string `yaml:"header,omitempty"`
+	Body   string            `yaml:"body"`
+}
+
+// Records the responses of a client to a cassette, or replays them in tests without calling the API.
+// Requests are never stored, so the API key stays out of the cassette.
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	mode     RecorderMode
+	scrub    func(body []byte) []byte
+	cassette Cassette
+	replayed map[int]bool
+}
+
+// Create a recorder for the cassette at path, replaying reads the cassette now
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, replayed: make(map[int]bool)}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(body, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Rewrite each recorded body before it is stored, e.g. with ScrubPersonalData.
+// The client still gets the original body.
+func (r *Recorder) SetScrubber(scrub func(body []byte) []byte) *Recorder {
+	r.scrub = scrub
+	return r
+}
+
+// Add the recorder to a client, the client gets its own copy of a transport shared with other clients
+func (r *Recorder) Attach(client *req.Client) *req.Client {
+	transport := client.GetTransport().Clone()
+	transport.WrapRoundTripFunc(r.roundTrip)
+	return SetTransport(client, transport)
+}
+
+// Write the recorded calls to the cassette
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, err := yaml.Marshal(r.cassette)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, body, 0o644)
+}
+
+func (r *Recorder) roundTrip(rt http.RoundTripper) req.HttpRoundTripFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		if r.mode == ModeRecord {
+			return r.record(rt, request)
+		}
+		return r.replay(request)
+	}
+}
+
+func (r *Recorder) record(rt http.RoundTripper, request *http.Request) (*http.Response, error) {
+	resp, err := rt.RoundTrip(request)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if r.scrub != nil {
+		body = r.scrub(bytes.Clone(body))
+	}
+
+	interaction := Interaction{Method: request.Method, URL: request.URL.RequestURI(), Status: resp.StatusCode, Body: string(body)}
+	for _, name := range cassetteHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if interaction.Header == nil {
+				interaction.Header = make(map[string]string)
+			}
+			interaction.Header[name] = value
+		}
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Respond with the first call to the same method and URL that hasn't been replayed yet
+func (r *Recorder) replay(request *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || interaction.Method != request.Method || interaction.URL != request.URL.RequestURI() {
+			continue
+		}
+		r.replayed[i] = true
+		header := make(http.Header)
+		for name, value := range interaction.Header {
+			header.Set(name, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Body))),
+			ContentLength: int64(len(interaction.Body)),
+			Request:       request,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s has no response for %s %s", r.path, request.Method, request.URL.RequestURI())
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// Fields holding the name of a person, if the object also has an email
+var personNameFields = []string{"name", "displayName", "firstName", "lastName"}
+
+// Replace email addresses with user@example.com and the names of people, i.e. objects with an
+// email such as team members and owners, with "Redacted". Bodies that aren't JSON only have their
+// emails replaced.
+func ScrubPersonalData(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		scrubPeople(value)
+		if scrubbed, err := json.Marshal(value); err == nil {
+			body = scrubbed
+		}
+	}
+	return emailPattern.ReplaceAll(body, []byte("user@example.com"))
+}
+
+func scrubPeople(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if _, ok := value["email"]; ok {
+			for _, field := range personNameFields {
+				if _, ok := value[field].(string); ok {
+					value[field] = "Redacted"
+				}
+			}
+		}
+		for _, item := range value {
+			scrubPeople(item)
+		}
+	case []interface{}:
+		for _, item := range value {
+			scrubPeople(item)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cortexapi
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"items": ["a"], "page": 0, "totalPages": 2}`, http.Header{"Set-Cookie": []string{"session=secret"}, "Content-Type": []string{"application/json"}}),
+		gh.RespondWith(http.StatusOK, `{"items": ["b"], "page": 1, "totalPages": 2}`),
+	)
+	path := filepath.Join(t.TempDir(), "items.yaml")
+	baseURL := server.URL()
+
+	// Record from the server
+	recorder, err := NewRecorder(path, ModeRecord)
+	g.Expect(err).To(BeNil())
+	items, err := listItems(recorder.Attach(NewClient(baseURL, "fake_api_key")))
+	g.Expect(err).To(BeNil())
+	g.Expect(items).To(Equal([]string{"a", "b"}))
+	g.Expect(recorder.Save()).To(Succeed())
+	server.Close()
+
+	// Neither the key nor the cookie are recorded
+	cassette, err := os.ReadFile(path)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(cassette)).ToNot(ContainSubstring("fake_api_key"))
+	g.Expect(string(cassette)).ToNot(ContainSubstring("secret"))
+	g.Expect(string(cassette)).To(ContainSubstring("url: /api/v1/items?page=1&pageSize=1000"))
+
+	// Replay without the server
+	recorder, err = NewRecorder(path, ModeReplay)
+	g.Expect(err).To(BeNil())
+	client := recorder.Attach(NewClient(baseURL, "fake_api_key").SetCommonRetryCount(0))
+	items, err = listItems(client)
+	g.Expect(err).To(BeNil())
+	g.Expect(items).To(Equal([]string{"a", "b"}))
+
+	// Each response is only replayed once
+	_, err = listItems(client)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("has no response for GET /api/v1/items?page=0&pageSize=1000"))
+}
+
+func TestRecorderScrubber(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	body := `{"items": ["alice@example.org"], "page": 0, "totalPages": 1, "owner": {"name": "Alice Smith", "email": "alice@example.org"}}`
+	server.AppendHandlers(gh.RespondWith(http.StatusOK, body))
+	path := filepath.Join(t.TempDir(), "items.yaml")
+
+	recorder, err := NewRecorder(path, ModeRecord)
+	g.Expect(err).To(BeNil())
+	items, err := listItems(recorder.SetScrubber(ScrubPersonalData).Attach(NewClient(server.URL(), "fake_api_key")))
+	g.Expect(err).To(BeNil())
+	// The client gets the original body
+	g.Expect(items).To(Equal([]string{"alice@example.org"}))
+	g.Expect(recorder.Save()).To(Succeed())
+
+	cassette, err := os.ReadFile(path)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(cassette)).ToNot(ContainSubstring("alice@example.org"))
+	g.Expect(string(cassette)).ToNot(ContainSubstring("Alice Smith"))
+}
+
+func TestScrubPersonalData(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		Body     string
+		Expected string
+	}{
+		// Only the names of objects with an email are people
+		{
+			`{"teamTag": "payments", "metadata": {"name": "Payments"}, "members": [{"name": "Bob Jones", "email": "bob@acme.io", "notificationsEnabled": true}]}`,
+			`{"members":[{"email":"user@example.com","name":"Redacted","notificationsEnabled":true}],"metadata":{"name":"Payments"},"teamTag":"payments"}`,
+		},
+		// Numbers are kept as they are
+		{`{"id": 12345678901234567890}`, `{"id":12345678901234567890}`},
+		{"owner bob@acme.io not found", "owner user@example.com not found"},
+	}
+	for _, test := range tests {
+		g.Expect(string(ScrubPersonalData([]byte(test.Body)))).To(Equal(test.Expected))
+	}
+}
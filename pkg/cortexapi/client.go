@@ -0,0 +1,89 @@
+// Package cortexapi is a client for the Cortex REST API, it can be used outside of steampipe.
+package cortexapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultBaseURL = "https://api.getcortexapp.com"
+
+// Response header identifying a call, quote it in support tickets with Cortex
+const RequestIDHeader = "X-Request-Id"
+
+// Create a req http client for the Cortex API with the base URL and auth set.
+// Failed connections of GET requests are retried twice, the retry condition can be replaced to limit retries further.
+// Responses are decoded as YAML, a superset of JSON, so models only need yaml tags.
+func NewClient(baseURL string, apiKey string) *req.Client {
+	client := SetTransport(req.C(), NewTransport())
+	return client.
+		SetBaseURL(baseURL).
+		SetCommonBearerAuthToken(apiKey).
+		SetJsonUnmarshal(yaml.Unmarshal).
+		// Capped exponential backoff with jitter
+		SetCommonRetryCount(2).
+		SetCommonRetryBackoffInterval(time.Second, 5*time.Second).
+		SetCommonRetryCondition(func(resp *req.Response, err error) bool {
+			return err != nil && IsSafeMethod(resp.Request.Method)
+		})
+}
+
+// Idle connections kept per host, enough for the calls a scan makes at once to reuse them
+const DefaultMaxIdleConnsPerHost = 20
+
+// Create a transport for the Cortex API. HTTP/2 is preferred and HTTP/1.1 is used when the
+// server doesn't support it, idle connections are kept so later calls skip the TLS handshake.
+func NewTransport() *req.Transport {
+	transport := req.T()
+	transport.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	return transport
+}
+
+// Send the requests of a client with the transport, clients sharing a transport share its connections
+func SetTransport(client *req.Client, transport *req.Transport) *req.Client {
+	client.Transport = transport
+	client.GetClient().Transport = transport
+	return client
+}
+
+// Whether a request can be sent again after a failed connection. A POST, e.g. submitting
+// a CQL query, may have reached Cortex before the connection failed and would run twice.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// A failed call to the Cortex API, the status code is kept so callers can handle errors by code
+type Error struct {
+	StatusCode int
+	Status     string
+	RequestID  string
+	Body       string
+}
+
+func (e *Error) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("error from cortex API %s (request id %s): %s", e.Status, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("error from cortex API %s: %s", e.Status, e.Body)
+}
+
+// Error for a failed call, includes the request id when the API returned one
+func NewError(resp *req.Response) error {
+	// Streamed responses haven't read the body yet
+	body, _ := resp.ToBytes()
+	return &Error{
+		StatusCode: resp.GetStatusCode(),
+		Status:     resp.Status,
+		RequestID:  resp.GetHeader(RequestIDHeader),
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,80 @@
+package cortexapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/imroc/req/v3"
+)
+
+// Position in a paginated list, endpoints return either page indexes or a cursor to the next page.
+type Pagination struct {
+	Page       int
+	TotalPages int
+	NextCursor string
+}
+
+// Implemented by the responses of paginated endpoints.
+type PaginatedResponse interface {
+	Pagination() Pagination
+}
+
+// Fetch every page of a list endpoint and pass each response to handle.
+// The request func should build a fresh request with any filters, pagination params are added here.
+// Returning false from handle stops fetching more pages, e.g. when the row limit has been hit.
+func Paginate[T PaginatedResponse](ctx context.Context, request func() *req.Request, handle func(response T) (bool, error)) error {
+	return paginate(ctx, request, func(resp *req.Response) (Pagination, bool, error) {
+		// Unmarshal the response and check for unmarshal errors
+		var response T
+		err := resp.Into(&response)
+		if err != nil {
+			return Pagination{}, false, err
+		}
+		more, err := handle(response)
+		return response.Pagination(), more, err
+	})
+}
+
+// Request every page until decode returns false, decode reads a page and returns where the next one is
+func paginate(ctx context.Context, request func() *req.Request, decode func(resp *req.Response) (Pagination, bool, error)) error {
+	var page int = 0
+	var cursor string
+	for {
+		r := request().SetQueryParam("pageSize", "1000")
+		if cursor != "" {
+			r.SetQueryParam("cursor", cursor)
+		} else {
+			r.SetQueryParam("page", strconv.Itoa(page))
+		}
+		resp := r.Do(ctx)
+
+		// Check for HTTP errors
+		if resp.IsErrorState() {
+			return NewError(resp)
+		}
+
+		pagination, more, err := decode(resp)
+		if err != nil || !more {
+			return err
+		}
+
+		// Follow the cursor if the endpoint returned one, otherwise use page indexes
+		if pagination.NextCursor != "" {
+			// A page pointing at itself would be requested forever, e.g. a fixture that ignores the cursor
+			if pagination.NextCursor == cursor {
+				return fmt.Errorf("%s returned its own cursor %q as the next page", resp.Request.RawURL, cursor)
+			}
+			cursor = pagination.NextCursor
+			continue
+		}
+		if cursor != "" {
+			break
+		}
+		page++
+		if page >= pagination.TotalPages {
+			break
+		}
+	}
+	return nil
+}
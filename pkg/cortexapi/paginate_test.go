@@ -0,0 +1,158 @@
+package cortexapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type testResponse struct {
+	Items      []string `yaml:"items"`
+	Page       int      `yaml:"page"`
+	TotalPages int      `yaml:"totalPages"`
+	NextCursor string   `yaml:"nextCursor"`
+}
+
+func (r testResponse) Pagination() Pagination {
+	return Pagination{Page: r.Page, TotalPages: r.TotalPages, NextCursor: r.NextCursor}
+}
+
+func listItems(client *req.Client) ([]string, error) {
+	var items []string
+	request := func() *req.Request {
+		return client.Get("/api/v1/items")
+	}
+	err := Paginate(context.Background(), request, func(response testResponse) (bool, error) {
+		items = append(items, response.Items...)
+		return true, nil
+	})
+	return items, err
+}
+
+func TestPaginatePages(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=0&pageSize=1000"),
+			gh.VerifyHeaderKV("Authorization", "Bearer fake_api_key"),
+			gh.RespondWith(http.StatusOK, `{"items": ["a", "b"], "page": 0, "totalPages": 2}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=1&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"items": ["c"], "page": 1, "totalPages": 2}`),
+		),
+	)
+
+	items, err := listItems(NewClient(server.URL(), "fake_api_key"))
+	g.Expect(err).To(BeNil())
+	g.Expect(items).To(Equal([]string{"a", "b", "c"}))
+}
+
+func TestPaginateCursor(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=0&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"items": ["a"], "nextCursor": "next"}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "cursor=next&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"items": ["b"]}`),
+		),
+	)
+
+	items, err := listItems(NewClient(server.URL(), "fake_api_key"))
+	g.Expect(err).To(BeNil())
+	g.Expect(items).To(Equal([]string{"a", "b"}))
+}
+
+func TestPaginateRepeatedCursor(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"items": ["a"], "nextCursor": "next"}`),
+		gh.RespondWith(http.StatusOK, `{"items": ["b"], "nextCursor": "next"}`),
+	)
+
+	items, err := listItems(NewClient(server.URL(), "fake_api_key"))
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring(`returned its own cursor "next" as the next page`))
+	g.Expect(items).To(Equal([]string{"a", "b"}))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(2))
+}
+
+func TestPaginateError(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusForbidden, `{}`, http.Header{RequestIDHeader: []string{"abc-123"}}),
+	)
+
+	_, err := listItems(NewClient(server.URL(), "fake_api_key"))
+	g.Expect(err).To(Equal(&Error{StatusCode: http.StatusForbidden, Status: "403 Forbidden", RequestID: "abc-123", Body: "{}"}))
+	g.Expect(err.Error()).To(Equal("error from cortex API 403 Forbidden (request id abc-123): {}"))
+}
+
+func TestNewClientRetriesOnlySafeMethods(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	dropConnection := func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		g.Expect(err).To(BeNil())
+		conn.Close()
+	}
+	server.AppendHandlers(
+		dropConnection,
+		gh.RespondWith(http.StatusOK, `{"items": ["a"]}`),
+		dropConnection,
+	)
+	client := NewClient(server.URL(), "fake_api_key").SetCommonRetryFixedInterval(time.Millisecond)
+
+	items, err := listItems(client)
+	g.Expect(err).To(BeNil())
+	g.Expect(items).To(Equal([]string{"a"}))
+
+	resp := client.Post("/api/v1/items").Do(context.Background())
+	g.Expect(resp.Err).ToNot(BeNil())
+	g.Expect(server.ReceivedRequests()).To(HaveLen(3))
+}
+
+func TestNewClientNegotiatesHTTP2(t *testing.T) {
+	g := NewWithT(t)
+	for _, http2 := range []bool{true, false} {
+		server := ghttp.NewUnstartedServer()
+		server.HTTPTestServer.EnableHTTP2 = http2
+		server.HTTPTestServer.StartTLS()
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"items": []}`))
+
+		resp := NewClient(server.URL(), "fake_api_key").
+			EnableInsecureSkipVerify().
+			Get("/api/v1/items").
+			Do(context.Background())
+		g.Expect(resp.Err).To(BeNil())
+		// Servers without HTTP/2 fall back to HTTP/1.1
+		if http2 {
+			g.Expect(resp.Proto).To(Equal("HTTP/2.0"))
+		} else {
+			g.Expect(resp.Proto).To(Equal("HTTP/1.1"))
+		}
+		server.Close()
+	}
+}
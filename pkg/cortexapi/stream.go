@@ -0,0 +1,149 @@
+package cortexapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/imroc/req/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Pagination fields of a response, the Cortex API names them the same on every list endpoint
+type pageFields struct {
+	Page       int    `yaml:"page" json:"page"`
+	TotalPages int    `yaml:"totalPages" json:"totalPages"`
+	NextCursor string `yaml:"nextCursor" json:"nextCursor"`
+}
+
+// Like Paginate, but the items in the list field of each page, e.g. "entities", are passed to handle one at a time
+// as the body is read. A page is never held in memory whole, so responses of 100MB+ don't spike memory.
+// Bodies that aren't JSON, e.g. YAML fixtures, are decoded whole.
+func PaginateItems[T any](ctx context.Context, request func() *req.Request, field string, handle func(item T) (bool, error)) error {
+	stream := func() *req.Request {
+		return request().DisableAutoReadResponse()
+	}
+	return paginate(ctx, stream, func(resp *req.Response) (Pagination, bool, error) {
+		if resp.Err != nil {
+			return Pagination{}, false, resp.Err
+		}
+		defer resp.Body.Close()
+		return decodeItems(resp.Body, field, handle)
+	})
+}
+
+func decodeItems[T any](body io.Reader, field string, handle func(item T) (bool, error)) (Pagination, bool, error) {
+	reader := bufio.NewReader(body)
+	if !startsWithObject(reader) {
+		return decodeItemsWhole(reader, field, handle)
+	}
+
+	decoder := json.NewDecoder(reader)
+	if _, err := decoder.Token(); err != nil {
+		return Pagination{}, false, err
+	}
+	var fields pageFields
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return Pagination{}, false, err
+		}
+		switch token {
+		case field:
+			more, err := decodeArray(decoder, field, handle)
+			if err != nil || !more {
+				return Pagination{}, false, err
+			}
+		case "page":
+			err = decoder.Decode(&fields.Page)
+		case "totalPages":
+			err = decoder.Decode(&fields.TotalPages)
+		case "nextCursor":
+			err = decoder.Decode(&fields.NextCursor)
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+		}
+		if err != nil {
+			return Pagination{}, false, err
+		}
+	}
+	return Pagination{Page: fields.Page, TotalPages: fields.TotalPages, NextCursor: fields.NextCursor}, true, nil
+}
+
+// Decode the items of the array the decoder is at, false if handle stopped early
+func decodeArray[T any](decoder *json.Decoder, field string, handle func(item T) (bool, error)) (bool, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		return true, nil
+	}
+	if token != json.Delim('[') {
+		return false, fmt.Errorf("%s should be an array, got %v", field, token)
+	}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return false, err
+		}
+		// Models only have yaml tags, JSON is YAML
+		var item T
+		if err := yaml.Unmarshal(raw, &item); err != nil {
+			return false, err
+		}
+		more, err := handle(item)
+		if err != nil || !more {
+			return false, err
+		}
+	}
+	_, err = decoder.Token()
+	return true, err
+}
+
+func decodeItemsWhole[T any](reader io.Reader, field string, handle func(item T) (bool, error)) (Pagination, bool, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return Pagination{}, false, err
+	}
+	var document map[string]yaml.Node
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return Pagination{}, false, err
+	}
+	var items []T
+	if node, ok := document[field]; ok {
+		if err := node.Decode(&items); err != nil {
+			return Pagination{}, false, err
+		}
+	}
+	var fields pageFields
+	if err := yaml.Unmarshal(body, &fields); err != nil {
+		return Pagination{}, false, err
+	}
+	for _, item := range items {
+		more, err := handle(item)
+		if err != nil || !more {
+			return Pagination{}, false, err
+		}
+	}
+	return Pagination{Page: fields.Page, TotalPages: fields.TotalPages, NextCursor: fields.NextCursor}, true, nil
+}
+
+// Whether the body is a JSON object, leading whitespace is skipped
+func startsWithObject(reader *bufio.Reader) bool {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		_ = reader.UnreadByte()
+		return b == '{'
+	}
+}
@@ -0,0 +1,111 @@
+package cortexapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+type testItem struct {
+	Name string `yaml:"name"`
+}
+
+func streamItems(client *req.Client, limit int) ([]string, error) {
+	var names []string
+	request := func() *req.Request {
+		return client.Get("/api/v1/items")
+	}
+	err := PaginateItems(context.Background(), request, "items", func(item testItem) (bool, error) {
+		names = append(names, item.Name)
+		return len(names) < limit, nil
+	})
+	return names, err
+}
+
+func TestPaginateItemsPages(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		// Pagination fields can come after the items
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=0&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"total": 3, "items": [{"name": "a", "other": [1, 2]}, {"name": "b"}], "page": 0, "totalPages": 2}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=1&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, "items:\n  - name: c\npage: 1\ntotalPages: 2\n"),
+		),
+	)
+
+	names, err := streamItems(NewClient(server.URL(), "fake_api_key"), 100)
+	g.Expect(err).To(BeNil())
+	g.Expect(names).To(Equal([]string{"a", "b", "c"}))
+}
+
+func TestPaginateItemsCursor(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "page=0&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"items": [{"name": "a"}], "nextCursor": "next"}`),
+		),
+		ghttp.CombineHandlers(
+			gh.VerifyRequest("GET", "/api/v1/items", "cursor=next&pageSize=1000"),
+			gh.RespondWith(http.StatusOK, `{"items": null, "nextCursor": null}`),
+		),
+	)
+
+	names, err := streamItems(NewClient(server.URL(), "fake_api_key"), 100)
+	g.Expect(err).To(BeNil())
+	g.Expect(names).To(Equal([]string{"a"}))
+}
+
+func TestPaginateItemsStopsEarly(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusOK, `{"items": [{"name": "a"}, {"name": "b"}, {"name": "c"}], "page": 0, "totalPages": 2}`),
+	)
+
+	names, err := streamItems(NewClient(server.URL(), "fake_api_key"), 2)
+	g.Expect(err).To(BeNil())
+	g.Expect(names).To(Equal([]string{"a", "b"}))
+	g.Expect(server.ReceivedRequests()).To(HaveLen(1))
+}
+
+func TestPaginateItemsErrors(t *testing.T) {
+	g := NewWithT(t)
+	gh := ghttp.NewGHTTPWithGomega(g)
+	server := ghttp.NewServer()
+	defer server.Close()
+	server.AppendHandlers(
+		gh.RespondWith(http.StatusForbidden, `{"message": "forbidden"}`),
+		gh.RespondWith(http.StatusOK, `{"items": {"name": "a"}}`),
+		gh.RespondWith(http.StatusOK, `{"items": [{"name": "a"}`),
+	)
+	client := NewClient(server.URL(), "fake_api_key").SetCommonRetryCount(0)
+
+	// The body of a streamed error is still read
+	_, err := streamItems(client, 100)
+	var apiErr *Error
+	g.Expect(err).To(BeAssignableToTypeOf(apiErr))
+	g.Expect(err.(*Error).StatusCode).To(Equal(http.StatusForbidden))
+	g.Expect(err.(*Error).Body).To(Equal(`{"message": "forbidden"}`))
+
+	_, err = streamItems(client, 100)
+	g.Expect(err).To(MatchError(ContainSubstring("items should be an array")))
+
+	_, err = streamItems(client, 100)
+	g.Expect(err).ToNot(BeNil())
+}